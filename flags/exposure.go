@@ -0,0 +1,237 @@
+package flags
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Exposure is emitted every time a flag is evaluated for a user
+type Exposure struct {
+	FlagKey   string    `json:"flagKey"`
+	Variant   string    `json:"variant"`
+	UserKey   string    `json:"userKey"`
+	Source    string    `json:"source"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ExposureHook receives the exposures of flag evaluations. OnExposure is called on
+// the evaluation path, so implementations must return quickly (see ExposureBatcher).
+type ExposureHook interface {
+	OnExposure(e Exposure)
+}
+
+// ExposureSink persists or ships a batch of exposures
+type ExposureSink interface {
+	WriteExposures(list []Exposure) error
+}
+
+// NewExposureBatcher
+// creates a hook which buffers exposures and writes them to sink in batches of batchSize,
+// or every flushInterval, whichever comes first. The batches are written by a background
+// goroutine, never on the evaluation path. An exposure with the same flag, variant
+// and user as one seen within dedupWindow is dropped. A batch the sink fails to write
+// is kept for the next flush, and at most 10 batches are buffered (see WithMaxBuffer).
+// Call Close to flush the rest.
+func NewExposureBatcher(sink ExposureSink, batchSize int, flushInterval, dedupWindow time.Duration) *ExposureBatcher {
+	if sink == nil {
+		panic("sink cannot be nil")
+	}
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	if flushInterval <= 0 {
+		flushInterval = 10 * time.Second
+	}
+	var b = &ExposureBatcher{
+		sink:        sink,
+		batchSize:   batchSize,
+		maxBuffer:   10 * batchSize,
+		dedupWindow: dedupWindow,
+		lock:        &sync.Mutex{},
+		seen:        map[string]time.Time{},
+		full:        make(chan struct{}, 1),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+	go b.loop(flushInterval)
+	return b
+}
+
+type ExposureBatcher struct {
+	sink        ExposureSink
+	batchSize   int
+	maxBuffer   int
+	dedupWindow time.Duration
+
+	lock   *sync.Mutex
+	buffer []Exposure
+	// dropped counts the exposures dropped as the buffer is full
+	dropped int64
+	seen    map[string]time.Time
+	closed  bool
+	// full signals the background goroutine that a batch is full
+	full    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// WithMaxBuffer sets how many exposures are buffered at most while the sink is slow or
+// failing, the oldest ones are dropped beyond it. It is not below the batch size.
+func (b *ExposureBatcher) WithMaxBuffer(n int) *ExposureBatcher {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if n < b.batchSize {
+		n = b.batchSize
+	}
+	b.maxBuffer = n
+	return b
+}
+
+// Dropped returns how many exposures are dropped as the buffer is full
+func (b *ExposureBatcher) Dropped() int64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.dropped
+}
+
+// OnExposure buffers e, unless it is a duplicate within the de-duplication window or the
+// batcher is closed. When the batch is full, it is handed to the background goroutine,
+// so the sink is never called on the evaluation path.
+func (b *ExposureBatcher) OnExposure(e Exposure) {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	b.lock.Lock()
+	if b.closed {
+		b.lock.Unlock()
+		return
+	}
+	if b.dedupWindow > 0 {
+		var key = e.FlagKey + "\x00" + e.Variant + "\x00" + e.UserKey
+		if last, ok := b.seen[key]; ok && e.Timestamp.Sub(last) < b.dedupWindow {
+			b.lock.Unlock()
+			return
+		}
+		b.seen[key] = e.Timestamp
+	}
+	b.buffer = append(b.buffer, e)
+	b.trim()
+	var full = len(b.buffer) >= b.batchSize
+	b.lock.Unlock()
+
+	if full {
+		select {
+		case b.full <- struct{}{}:
+		default:
+			// a flush is already requested
+		}
+	}
+}
+
+// trim drops the oldest exposures beyond the maximum buffer size, b.lock must be held
+func (b *ExposureBatcher) trim() {
+	if over := len(b.buffer) - b.maxBuffer; over > 0 {
+		b.dropped += int64(over)
+		b.buffer = append([]Exposure(nil), b.buffer[over:]...)
+	}
+}
+
+// Flush writes the buffered exposures to the sink right away. If the sink fails,
+// they are kept in the buffer, ahead of the ones buffered meanwhile.
+func (b *ExposureBatcher) Flush() error {
+	b.lock.Lock()
+	var batch = b.buffer
+	b.buffer = nil
+	b.lock.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+	err := b.sink.WriteExposures(batch)
+	if err != nil {
+		b.lock.Lock()
+		b.buffer = append(batch, b.buffer...)
+		b.trim()
+		b.lock.Unlock()
+	}
+	return err
+}
+
+// Close stops the background flushing and flushes the remaining exposures
+func (b *ExposureBatcher) Close() error {
+	b.lock.Lock()
+	if b.closed {
+		b.lock.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.lock.Unlock()
+	close(b.done)
+	<-b.stopped
+	return b.Flush()
+}
+
+func (b *ExposureBatcher) loop(flushInterval time.Duration) {
+	defer close(b.stopped)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-b.full:
+			if err := b.Flush(); err != nil {
+				fmt.Printf("[flags] -> failed to write exposures: %s\n", err.Error())
+			}
+		case now := <-ticker.C:
+			if err := b.Flush(); err != nil {
+				fmt.Printf("[flags] -> failed to write exposures: %s\n", err.Error())
+			}
+			b.forget(now)
+		}
+	}
+}
+
+// forget drops the de-duplication entries which are out of the window
+func (b *ExposureBatcher) forget(now time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for k, t := range b.seen {
+		if now.Sub(t) >= b.dedupWindow {
+			delete(b.seen, k)
+		}
+	}
+}
+
+// NewJSONLSink creates a sink which appends exposures to the file at path,
+// one JSON object per line
+func NewJSONLSink(path string) (*JSONLSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLSink{file: file, lock: &sync.Mutex{}}, nil
+}
+
+type JSONLSink struct {
+	file *os.File
+	lock *sync.Mutex
+}
+
+func (s *JSONLSink) WriteExposures(list []Exposure) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var enc = json.NewEncoder(s.file)
+	for _, e := range list {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *JSONLSink) Close() error {
+	return s.file.Close()
+}
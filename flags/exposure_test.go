@@ -0,0 +1,181 @@
+package flags
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mosix-go-configmapper/inputs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memorySink struct {
+	lock    sync.Mutex
+	batches [][]Exposure
+}
+
+func (s *memorySink) WriteExposures(list []Exposure) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.batches = append(s.batches, list)
+	return nil
+}
+
+func (s *memorySink) count() (batches, exposures int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, b := range s.batches {
+		exposures += len(b)
+	}
+	return len(s.batches), exposures
+}
+
+func TestExposureBatcher_BatchesAndDeduplicates(t *testing.T) {
+	inputMock := inputs.NewInputMock()
+	inputMock.KeysBool["ON"] = true
+	sink := &memorySink{}
+	batcher := NewExposureBatcher(sink, 2, time.Hour, time.Hour)
+	flag := New("ON", inputMock).WithExposureHook(batcher)
+
+	flag.Enabled(Context{UserID: "joe"})
+	flag.Enabled(Context{UserID: "joe"})
+	batches, _ := sink.count()
+	assert.Equal(t, 0, batches, "the repeated exposure must be dropped")
+
+	flag.Enabled(Context{UserID: "jane"})
+	assert.Eventually(t, func() bool {
+		batches, exposures := sink.count()
+		return batches == 1 && exposures == 2
+	}, time.Second, time.Millisecond, "a full batch must be written in background")
+
+	flag.Enabled(Context{UserID: "bob"})
+	assert.NoError(t, batcher.Close())
+	batches, exposures := sink.count()
+	assert.Equal(t, 2, batches)
+	assert.Equal(t, 3, exposures)
+	last := sink.batches[1][0]
+	assert.False(t, last.Timestamp.IsZero())
+	last.Timestamp = time.Time{}
+	assert.Equal(t, Exposure{FlagKey: "ON", Variant: VariantOn, UserKey: "bob", Source: inputs.InputMockName}, last)
+
+	flag.Enabled(Context{UserID: "alice"})
+	_, exposures = sink.count()
+	assert.Equal(t, 3, exposures, "a closed batcher must drop exposures")
+}
+
+// blockingSink blocks its writes until release is closed
+type blockingSink struct {
+	memorySink
+	release chan struct{}
+}
+
+func (s *blockingSink) WriteExposures(list []Exposure) error {
+	<-s.release
+	return s.memorySink.WriteExposures(list)
+}
+
+func TestExposureBatcher_FullBatchDoesNotBlockEvaluation(t *testing.T) {
+	inputMock := inputs.NewInputMock()
+	inputMock.KeysBool["ON"] = true
+	sink := &blockingSink{release: make(chan struct{})}
+	batcher := NewExposureBatcher(sink, 1, time.Hour, 0)
+	flag := New("ON", inputMock).WithExposureHook(batcher)
+
+	var evaluated = make(chan struct{})
+	go func() {
+		for _, user := range []string{"joe", "jane", "bob"} {
+			flag.Enabled(Context{UserID: user})
+		}
+		close(evaluated)
+	}()
+	select {
+	case <-evaluated:
+	case <-time.After(time.Second):
+		t.Fatal("evaluations must not wait for the sink")
+	}
+	close(sink.release)
+	assert.NoError(t, batcher.Close())
+	_, exposures := sink.count()
+	assert.Equal(t, 3, exposures)
+}
+
+// failingSink fails every write
+type failingSink struct {
+	writes atomic.Int64
+}
+
+func (s *failingSink) WriteExposures(list []Exposure) error {
+	s.writes.Add(1)
+	return errors.New("sink is down")
+}
+
+func TestExposureBatcher_BoundsBufferWhileSinkFails(t *testing.T) {
+	sink := &failingSink{}
+	batcher := NewExposureBatcher(sink, 2, 5*time.Millisecond, 0).WithMaxBuffer(4)
+	for i := 0; i < 10; i++ {
+		batcher.OnExposure(Exposure{FlagKey: "A", Variant: VariantOn, UserKey: fmt.Sprint("user", i)})
+	}
+	assert.Eventually(t, func() bool { return sink.writes.Load() >= 2 }, time.Second, time.Millisecond)
+	assert.Error(t, batcher.Close())
+	assert.Equal(t, int64(6), batcher.Dropped())
+	// the oldest exposures are dropped, the newest are kept for the next flush
+	var users []string
+	for _, e := range batcher.buffer {
+		users = append(users, e.UserKey)
+	}
+	assert.Equal(t, []string{"user6", "user7", "user8", "user9"}, users)
+}
+
+func TestExposureBatcher_FlushesOnInterval(t *testing.T) {
+	sink := &memorySink{}
+	batcher := NewExposureBatcher(sink, 100, 20*time.Millisecond, 0)
+	defer batcher.Close()
+	batcher.OnExposure(Exposure{FlagKey: "A", Variant: VariantOn, UserKey: "joe"})
+	batcher.OnExposure(Exposure{FlagKey: "A", Variant: VariantOn, UserKey: "joe"})
+
+	assert.Eventually(t, func() bool {
+		_, exposures := sink.count()
+		return exposures == 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestExposure_NotEmittedOnFailure(t *testing.T) {
+	sink := &memorySink{}
+	batcher := NewExposureBatcher(sink, 1, time.Hour, 0)
+	defer batcher.Close()
+	New("UNKNOWN", inputs.NewInputMock()).WithExposureHook(batcher).Evaluate(Context{UserID: "joe"})
+	_, exposures := sink.count()
+	assert.Equal(t, 0, exposures)
+}
+
+func TestJSONLSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exposures.jsonl")
+	sink, err := NewJSONLSink(path)
+	assert.NoError(t, err)
+	assert.NoError(t, sink.WriteExposures([]Exposure{{FlagKey: "A", Variant: "on", UserKey: "joe"}}))
+	assert.NoError(t, sink.WriteExposures([]Exposure{{FlagKey: "B", Variant: "red", UserKey: "jane"}}))
+	assert.NoError(t, sink.Close())
+
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+	var lines []Exposure
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var e Exposure
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		lines = append(lines, e)
+	}
+	if assert.Len(t, lines, 2) {
+		assert.Equal(t, "A", lines[0].FlagKey)
+		assert.Equal(t, "red", lines[1].Variant)
+	}
+}
@@ -0,0 +1,275 @@
+package flags
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"mosix-go-configmapper/inputs"
+)
+
+// the prefix a definition may carry, the same as json.object:: syntax of the mapper
+const jsonObjectSyntax = "json.object::"
+
+// reasons of an evaluation result
+const (
+	ReasonNotFound       = "notFound"
+	ReasonError          = "error"
+	ReasonDisabled       = "disabled"
+	ReasonDenied         = "denied"
+	ReasonAllowed        = "allowed"
+	ReasonAttributeMatch = "attributeMatch"
+	ReasonRollout        = "rollout"
+	ReasonOutOfRollout   = "outOfRollout"
+	ReasonDefault        = "default"
+)
+
+// names of the variants of flags which define no variants
+const (
+	VariantOn  = "on"
+	VariantOff = "off"
+)
+
+// Context is the subject a flag is evaluated for
+type Context struct {
+	UserID     string
+	Attributes map[string]string
+}
+
+// Variant is a weighted value of a multivariate flag
+type Variant struct {
+	Name   string          `json:"name"`
+	Weight float64         `json:"weight"`
+	Value  json.RawMessage `json:"value"`
+}
+
+// Definition is how a flag is stored in an input, as a JSON text (with or without
+// json.object:: syntax). A plain boolean value is a flag which is on or off for everybody.
+type Definition struct {
+	Enabled bool `json:"enabled"`
+
+	// Allow and Deny are user IDs the flag is always on / off for, Deny wins
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+
+	// Attributes turns the flag on for users having any of the listed values of an attribute
+	Attributes map[string][]string `json:"attributes,omitempty"`
+
+	// Rollout is the percentage (0..100) of users the flag is on for, nil means everybody
+	Rollout *float64 `json:"rollout,omitempty"`
+
+	// Variants are served to the users the flag is on for, in proportion to their weights
+	Variants []Variant `json:"variants,omitempty"`
+	// DefaultVariant is served to the users the flag is off for
+	DefaultVariant string `json:"defaultVariant,omitempty"`
+
+	// Seed changes the bucketing of users, the flag key is used if empty
+	Seed string `json:"seed,omitempty"`
+}
+
+// Result is the outcome of evaluating a flag
+type Result struct {
+	Enabled bool
+	Variant string
+	Value   json.RawMessage
+	Reason  string
+	// Source is the name of the input the flag is read from
+	Source string
+	Err    error
+}
+
+// Decode decodes the value of the variant into v
+func (r Result) Decode(v any) error {
+	if r.Err != nil {
+		return r.Err
+	}
+	if len(r.Value) == 0 {
+		return errors.New("variant has no value")
+	}
+	return json.Unmarshal(r.Value, v)
+}
+
+// New creates a flag which is looked up by key in the given inputs, in their order.
+// The definition is read on every evaluation, so reloading the inputs changes the flag.
+func New(key string, in ...inputs.ValueInputInterface) *Flag {
+	return &Flag{key: key, inputs: in}
+}
+
+type Flag struct {
+	key    string
+	inputs []inputs.ValueInputInterface
+	hook   ExposureHook
+}
+
+// WithExposureHook makes every evaluation of the flag, which is not
+// failed, be reported to hook
+func (f *Flag) WithExposureHook(hook ExposureHook) *Flag {
+	f.hook = hook
+	return f
+}
+
+func (f *Flag) Key() string {
+	return f.key
+}
+
+// IsBound reports whether the flag has any input to be evaluated from
+func (f *Flag) IsBound() bool {
+	return f != nil && len(f.inputs) > 0
+}
+
+// Enabled reports whether the flag is on for ctx
+func (f *Flag) Enabled(ctx Context) bool {
+	return f.Evaluate(ctx).Enabled
+}
+
+// Evaluate evaluates the flag for ctx
+func (f *Flag) Evaluate(ctx Context) Result {
+	if !f.IsBound() {
+		return Result{Variant: VariantOff, Reason: ReasonNotFound, Err: errors.New("flag is not bound to any input")}
+	}
+	def, source, err := f.definition()
+	if err != nil {
+		var reason = ReasonError
+		if errors.Is(err, errNotFound) {
+			reason = ReasonNotFound
+		}
+		return Result{Variant: VariantOff, Reason: reason, Source: source, Err: err}
+	}
+	var res = evaluate(f.key, def, ctx)
+	res.Source = source
+	if f.hook != nil {
+		f.hook.OnExposure(Exposure{
+			FlagKey:   f.key,
+			Variant:   res.Variant,
+			UserKey:   ctx.UserID,
+			Source:    source,
+			Timestamp: time.Now(),
+		})
+	}
+	return res
+}
+
+// Value evaluates the flag for ctx and decodes the value of its variant
+// into T, def is returned if the flag cannot be evaluated or decoded
+func Value[T any](f *Flag, ctx Context, def T) T {
+	var v T
+	if err := f.Evaluate(ctx).Decode(&v); err != nil {
+		return def
+	}
+	return v
+}
+
+var errNotFound = errors.New("flag is not found in any of the inputs")
+
+// definition returns the definition of the flag and the name of the input it is read from
+func (f *Flag) definition() (Definition, string, error) {
+	for _, in := range f.inputs {
+		if s, err := in.GetString(f.key); err == nil {
+			s = strings.TrimSpace(strings.TrimPrefix(s, jsonObjectSyntax))
+			var def Definition
+			if s == "true" || s == "false" {
+				def.Enabled = s == "true"
+				return def, in.GetInputName(), nil
+			}
+			if err := json.Unmarshal([]byte(s), &def); err != nil {
+				return def, in.GetInputName(), fmt.Errorf("flag %s has an invalid definition: %s", f.key, err.Error())
+			}
+			return def, in.GetInputName(), nil
+		}
+		if b, err := in.GetBoolean(f.key); err == nil {
+			return Definition{Enabled: b}, in.GetInputName(), nil
+		}
+	}
+	return Definition{}, "", errNotFound
+}
+
+func evaluate(key string, def Definition, ctx Context) Result {
+	var seed = def.Seed
+	if seed == "" {
+		seed = key
+	}
+	var on = func(reason string) Result {
+		var res = Result{Enabled: true, Variant: VariantOn, Value: json.RawMessage("true"), Reason: reason}
+		if v, ok := pickVariant(seed, def.Variants, ctx.UserID); ok {
+			res.Variant, res.Value = v.Name, v.Value
+		}
+		return res
+	}
+	var off = func(reason string) Result {
+		var res = Result{Variant: VariantOff, Value: json.RawMessage("false"), Reason: reason}
+		for _, v := range def.Variants {
+			if v.Name == def.DefaultVariant {
+				res.Variant, res.Value = v.Name, v.Value
+			}
+		}
+		return res
+	}
+
+	switch {
+	case !def.Enabled:
+		return off(ReasonDisabled)
+	case ctx.UserID != "" && contains(def.Deny, ctx.UserID):
+		return off(ReasonDenied)
+	case ctx.UserID != "" && contains(def.Allow, ctx.UserID):
+		return on(ReasonAllowed)
+	case matchesAttributes(def.Attributes, ctx.Attributes):
+		return on(ReasonAttributeMatch)
+	case def.Rollout == nil:
+		return on(ReasonDefault)
+	case ctx.UserID != "" && Bucket(seed, ctx.UserID) < *def.Rollout:
+		return on(ReasonRollout)
+	}
+	return off(ReasonOutOfRollout)
+}
+
+// Bucket deterministically maps a user to [0, 100) for a seed
+func Bucket(seed, userID string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(seed + "." + userID))
+	return float64(h.Sum32()%10000) / 100
+}
+
+// pickVariant picks a variant in proportion to the weights. Users without an ID
+// get the first variant, so they at least see a stable value.
+func pickVariant(seed string, variants []Variant, userID string) (Variant, bool) {
+	var total float64
+	for _, v := range variants {
+		total += v.Weight
+	}
+	if len(variants) == 0 {
+		return Variant{}, false
+	}
+	if total <= 0 || userID == "" {
+		return variants[0], true
+	}
+	var point = Bucket(seed+".variant", userID) / 100 * total
+	var sum float64
+	for _, v := range variants {
+		sum += v.Weight
+		if point < sum {
+			return v, true
+		}
+	}
+	return variants[len(variants)-1], true
+}
+
+func matchesAttributes(rules map[string][]string, attrs map[string]string) bool {
+	for name, values := range rules {
+		if v, ok := attrs[name]; ok && contains(values, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
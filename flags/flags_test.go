@@ -0,0 +1,75 @@
+package flags
+
+import (
+	"fmt"
+	"testing"
+
+	"mosix-go-configmapper/inputs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlag_Boolean(t *testing.T) {
+	inputMock := inputs.NewInputMock()
+	inputMock.KeysBool["SIMPLE_ON"] = true
+	inputMock.KeysStr["DISABLED"] = `{"enabled": false, "allow": ["joe"]}`
+	inputMock.KeysStr["TARGETED"] = `json.object::{"enabled": true, "allow": ["joe"], "deny": ["jane"],
+		"attributes": {"country": ["NL"]}, "rollout": 0}`
+
+	assert.True(t, New("SIMPLE_ON", inputMock).Enabled(Context{}))
+	assert.False(t, New("DISABLED", inputMock).Enabled(Context{UserID: "joe"}))
+
+	var targeted = New("TARGETED", inputMock)
+	assert.Equal(t, ReasonAllowed, targeted.Evaluate(Context{UserID: "joe"}).Reason)
+	assert.Equal(t, ReasonDenied, targeted.Evaluate(Context{UserID: "jane", Attributes: map[string]string{"country": "NL"}}).Reason)
+	assert.True(t, targeted.Enabled(Context{UserID: "bob", Attributes: map[string]string{"country": "NL"}}))
+	assert.False(t, targeted.Enabled(Context{UserID: "bob"}))
+
+	res := New("UNKNOWN", inputMock).Evaluate(Context{})
+	assert.False(t, res.Enabled)
+	assert.Equal(t, ReasonNotFound, res.Reason)
+	assert.Error(t, res.Err)
+
+	inputMock.KeysStr["BROKEN"] = `{`
+	assert.Equal(t, ReasonError, New("BROKEN", inputMock).Evaluate(Context{}).Reason)
+}
+
+func TestFlag_RolloutIsDeterministic(t *testing.T) {
+	inputMock := inputs.NewInputMock()
+	inputMock.KeysStr["HALF"] = `{"enabled": true, "rollout": 50}`
+	var flag = New("HALF", inputMock)
+
+	var on int
+	for i := 0; i < 1000; i++ {
+		var ctx = Context{UserID: fmt.Sprintf("user-%d", i)}
+		first := flag.Enabled(ctx)
+		assert.Equal(t, first, flag.Enabled(ctx))
+		if first {
+			on++
+		}
+	}
+	assert.InDelta(t, 500, on, 60)
+	assert.False(t, flag.Enabled(Context{}), "partial rollouts need a user ID")
+}
+
+func TestFlag_Multivariate(t *testing.T) {
+	inputMock := inputs.NewInputMock()
+	inputMock.KeysStr["BUTTON"] = `{"enabled": true, "defaultVariant": "blue", "variants": [
+		{"name": "red", "weight": 50, "value": "#f00"},
+		{"name": "green", "weight": 50, "value": "#0f0"},
+		{"name": "blue", "weight": 0, "value": "#00f"}]}`
+	inputMock.KeysStr["BUTTON_OFF"] = `{"enabled": false, "defaultVariant": "blue", "variants": [
+		{"name": "blue", "weight": 1, "value": "#00f"}]}`
+	var flag = New("BUTTON", inputMock)
+
+	var seen = map[string]bool{}
+	for i := 0; i < 100; i++ {
+		res := flag.Evaluate(Context{UserID: fmt.Sprintf("user-%d", i)})
+		seen[res.Variant] = true
+	}
+	assert.Equal(t, map[string]bool{"red": true, "green": true}, seen)
+
+	assert.Equal(t, "#00f", Value(New("BUTTON_OFF", inputMock), Context{UserID: "u1"}, "none"))
+	assert.Equal(t, "none", Value(New("UNKNOWN", inputMock), Context{}, "none"))
+	assert.Equal(t, 0, Value(flag, Context{UserID: "u1"}, 0), "a value of another type falls back to default")
+}
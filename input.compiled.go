@@ -0,0 +1,32 @@
+package configmapper
+
+import (
+	htmltemplate "html/template"
+	"reflect"
+	"regexp"
+	texttemplate "text/template"
+)
+
+var (
+	regexpType       = reflect.TypeOf(&regexp.Regexp{})
+	textTemplateType = reflect.TypeOf(&texttemplate.Template{})
+	htmlTemplateType = reflect.TypeOf(&htmltemplate.Template{})
+)
+
+func decodeRegexp(raw string, tag reflect.StructTag) (any, error) {
+	return regexp.Compile(raw)
+}
+
+// decodeTextTemplate parses a text/template, named after the key of the field
+func decodeTextTemplate(key string) Decoder {
+	return func(raw string, tag reflect.StructTag) (any, error) {
+		return texttemplate.New(key).Parse(raw)
+	}
+}
+
+// decodeHTMLTemplate parses an html/template, named after the key of the field
+func decodeHTMLTemplate(key string) Decoder {
+	return func(raw string, tag reflect.StructTag) (any, error) {
+		return htmltemplate.New(key).Parse(raw)
+	}
+}
@@ -0,0 +1,279 @@
+package configmapper
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"mosix-go-configmapper/types"
+	"reflect"
+	"strings"
+)
+
+// Decoder decodes the raw string of a key into a value of the type it is registered for,
+// tag is the tag of the field being mapped. See InputController.RegisterDecoder.
+type Decoder func(raw string, tag reflect.StructTag) (any, error)
+
+// RegisterDecoder
+// registers a decoder for the fields of type t (and *t), e.g. reflect.TypeOf(uuid.UUID{}).
+// Decoders are consulted before the built-in types, so registering a decoder for a
+// built-in type (e.g. tls.Certificate or an integer type of an enum field) overrides
+// it. They are used for the elements of slices and the values of maps as well.
+func (f *InputController) RegisterDecoder(t reflect.Type, decoder Decoder) *InputController {
+	if t == nil || decoder == nil {
+		panic("type and decoder cannot be nil")
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.decoders == nil {
+		f.decoders = map[reflect.Type]Decoder{}
+	}
+	f.decoders[t] = decoder
+	return f
+}
+
+// hasRegisteredDecoder reports whether a decoder is registered for t,
+// or for the element type of t if it is a pointer or a slice
+func (f *InputController) hasRegisteredDecoder(t reflect.Type) bool {
+	if _, ok := f.decoders[t]; ok {
+		return true
+	}
+	if t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		_, ok := f.decoders[t.Elem()]
+		return ok
+	}
+	return false
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	flagValueType       = reflect.TypeOf((*flag.Value)(nil)).Elem()
+)
+
+// isUnmarshaler reports whether a pointer to t implements encoding.TextUnmarshaler,
+// json.Unmarshaler or flag.Value, so values of t can be decoded from text
+func isUnmarshaler(t reflect.Type) bool {
+	var p = reflect.PointerTo(t)
+	return p.Implements(textUnmarshalerType) || p.Implements(jsonUnmarshalerType) || p.Implements(flagValueType)
+}
+
+// unmarshalText decodes raw into the value ptr points to, through encoding.TextUnmarshaler,
+// flag.Value or json.Unmarshaler, in this order. For json.Unmarshaler, raw is given as it is
+// if it is a valid JSON (with or without json.object:: syntax), or as a JSON string otherwise.
+func unmarshalText(ptr reflect.Value, raw string) error {
+	switch u := ptr.Interface().(type) {
+	case encoding.TextUnmarshaler:
+		return u.UnmarshalText([]byte(raw))
+	case flag.Value:
+		return u.Set(raw)
+	case json.Unmarshaler:
+		var data = []byte(strings.TrimPrefix(raw, SyntaxJsonObject))
+		if !json.Valid(data) {
+			data, _ = json.Marshal(raw)
+		}
+		return u.UnmarshalJSON(data)
+	}
+	return errors.New("type cannot be decoded from text")
+}
+
+// textDecoder returns a function which decodes a raw string of a key into a value of type t,
+// through a registered decoder or the unmarshaler interfaces t implements, nil if t has none
+// of them. A pointer type is decoded through the decoder of its element type as well.
+func (f *InputController) textDecoder(t reflect.Type) func(raw, key string, tag reflect.StructTag) (reflect.Value, error) {
+	if decoder, ok := f.decoders[t]; ok {
+		return typedDecoder(t, func(string) Decoder { return decoder })
+	}
+	if f.builtinDecoder(t, "") != nil {
+		return typedDecoder(t, func(key string) Decoder { return f.builtinDecoder(t, key) })
+	}
+	if t.Kind() == reflect.Pointer {
+		if elemDecoder := f.textDecoder(t.Elem()); elemDecoder != nil {
+			return func(raw, key string, tag reflect.StructTag) (reflect.Value, error) {
+				v, err := elemDecoder(raw, key, tag)
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				var ptr = reflect.New(t.Elem())
+				ptr.Elem().Set(v)
+				return ptr, nil
+			}
+		}
+		return nil
+	}
+	if isUnmarshaler(t) {
+		return func(raw, key string, tag reflect.StructTag) (reflect.Value, error) {
+			var ptr = reflect.New(t)
+			if err := unmarshalText(ptr, raw); err != nil {
+				return reflect.Value{}, err
+			}
+			return ptr.Elem(), nil
+		}
+	}
+	return nil
+}
+
+// typedDecoder makes sure the decoder of each key returns values of type t
+func typedDecoder(t reflect.Type, decoder func(key string) Decoder) func(raw, key string, tag reflect.StructTag) (reflect.Value, error) {
+	return func(raw, key string, tag reflect.StructTag) (reflect.Value, error) {
+		v, err := decoder(key)(raw, tag)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		var rv = reflect.ValueOf(v)
+		if !rv.IsValid() || !rv.Type().AssignableTo(t) {
+			return reflect.Value{}, fmt.Errorf("decoder of %s returned a value of type %T", t.String(), v)
+		}
+		return rv, nil
+	}
+}
+
+// builtinDecoder returns the decoder of the standard library types which are decoded
+// from text, nil for the other types. key is the key of the field being decoded.
+func (f *InputController) builtinDecoder(t reflect.Type, key string) Decoder {
+	switch t {
+	case timeType:
+		return decodeTime
+	case locationType:
+		return decodeLocation
+	case monthType:
+		return decodeMonth
+	case weekdayType:
+		return decodeWeekday
+	case addrType:
+		return decodeAddr
+	case prefixType:
+		return decodePrefix
+	case addrPortType:
+		return decodeAddrPort
+	case hardwareAddrType:
+		return decodeHardwareAddr
+	case regexpType:
+		return decodeRegexp
+	case textTemplateType:
+		return decodeTextTemplate(key)
+	case htmlTemplateType:
+		return decodeHTMLTemplate(key)
+	case certPoolType:
+		return decodeCertPool
+	case x509CertificatesType:
+		return decodeCertificates
+	case levelType:
+		return decodeLevel
+	case fileModeType:
+		return decodeFileMode
+	case urlValuesType:
+		return decodeURLValues
+	}
+	return nil
+}
+
+// builtinValidator returns the validator of the standard library types which are validated
+// as what they are, not as strings, nil for the other types. Pointers are validated by
+// the validator of their element type. key is the key of the field being validated.
+func (f *InputController) builtinValidator(t reflect.Type, key string) func(v reflect.Value, tag reflect.StructTag) error {
+	if t.Kind() == reflect.Pointer && t != locationType {
+		t = t.Elem()
+	}
+	if _, ok := f.decoders[t]; ok {
+		return nil
+	}
+	switch t {
+	case timeType:
+		return f.validateTime
+	case monthType, weekdayType:
+		return f.validateCalendarNumber
+	case addrType:
+		return f.validateAddr
+	case prefixType:
+		return f.validatePrefix
+	case addrPortType:
+		return f.validateAddrPort
+	case x509CertificatesType:
+		return f.validateCertificates(key)
+	case levelType:
+		return f.validateLevel(key)
+	case fileModeType:
+		return f.validateFileMode(key)
+	}
+	return nil
+}
+
+// mapDecoded maps the fields which have a registered decoder or implement
+// encoding.TextUnmarshaler, json.Unmarshaler or flag.Value, by decoding the resolved
+// string. Slices of such types are decoded from array.string:: values as well.
+// It reports whether the field was handled.
+func (f *InputController) mapDecoded(field reflect.Value, key string, tag *reflect.StructTag) bool {
+	var decode = f.textDecoder(field.Type())
+	var isSlice bool
+	if decode == nil && field.Kind() == reflect.Slice {
+		decode = f.textDecoder(field.Type().Elem())
+		isSlice = decode != nil
+		if isSlice && !f.exists(key, tag) && f.resolveDefault(tag) == "" {
+			// it may be given by indexed keys
+			return false
+		}
+	}
+	if decode == nil {
+		return false
+	}
+	var rules = f.getValidationTags(tag)
+	if _, ok := rules[types.VdRequired]; ok && !f.exists(key, tag) {
+		f.insertError(key, fmt.Errorf("field %s is required and must exist", key), ReasonNotFound)
+		return true
+	}
+	raw, skipped, err := f.resolveString(key, tag)
+	if err != nil || skipped {
+		return true
+	}
+
+	if isSlice {
+		values, _ := f.CheckStrArray(raw)
+		if values == nil {
+			values = []string{raw}
+		}
+		var slice = reflect.MakeSlice(field.Type(), 0, len(values))
+		for _, s := range values {
+			v, err := f.decodeString(strings.TrimSpace(s), key, field.Type().Elem(), *tag, rules, decode)
+			if err != nil {
+				f.insertError(key, err, ReasonValidation)
+				return true
+			}
+			slice = reflect.Append(slice, v)
+		}
+		field.Set(slice)
+		return true
+	}
+
+	v, err := f.decodeString(raw, key, field.Type(), *tag, rules, decode)
+	if err != nil {
+		f.insertError(key, err, ReasonValidation)
+		return true
+	}
+	field.Set(v)
+	return true
+}
+
+// decodeString runs the preprocessors and the string validations on raw and decodes it
+func (f *InputController) decodeString(raw, key string, t reflect.Type, tag reflect.StructTag, rules map[string]string,
+	decode func(raw, key string, tag reflect.StructTag) (reflect.Value, error)) (reflect.Value, error) {
+	var validate = f.builtinValidator(t, key)
+	raw, _, err := f.CheckStringPreProcessors(raw, rules)
+	if err == nil && validate == nil {
+		err = types.ValidateStrings(raw, rules)
+	}
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	v, err := decode(raw, key, tag)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to decode %s as %s: %s", key, strings.TrimPrefix(t.String(), "*"), err.Error())
+	}
+	if validate != nil {
+		if err := validate(reflect.Indirect(v), tag); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,188 @@
+package configmapper
+
+import (
+	"errors"
+	"fmt"
+	"mosix-go-configmapper/types"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// enumValue is a name of an enum tag and the number it stands for
+type enumValue struct {
+	name   string
+	number int64
+}
+
+// parseEnum parses an enum tag, e.g. off=0,shadow=1,enforce=2. A name with no number
+// stands for the number of the previous name plus one, starting from 0, e.g. off,shadow,enforce.
+func parseEnum(tag string) ([]enumValue, error) {
+	var values []enumValue
+	var next int64
+	for _, item := range strings.Split(tag, ",") {
+		name, number, hasNumber := strings.Cut(strings.TrimSpace(item), "=")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("enum %q has an empty name", tag)
+		}
+		if hasNumber {
+			n, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("number of enum name %s is not an integer", name)
+			}
+			next = n
+		}
+		for _, v := range values {
+			if strings.EqualFold(v.name, name) {
+				return nil, fmt.Errorf("enum name %s is repeated", name)
+			}
+		}
+		values = append(values, enumValue{name: name, number: next})
+		next++
+	}
+	return values, nil
+}
+
+// enumNames lists the names of an enum for the error messages
+func enumNames(values []enumValue) string {
+	var names = make([]string, 0, len(values))
+	for _, v := range values {
+		names = append(names, v.name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// mapEnum maps an integer field with an enum tag from the name resolved for its key,
+// case-insensitive. Unknown names are reported with the list of the names.
+// It reports whether the field was handled.
+func (f *InputController) mapEnum(field reflect.Value, key string, tag *reflect.StructTag) bool {
+	enum, ok := tag.Lookup(EnumTagName)
+	if !ok {
+		return false
+	}
+	var kind = field.Kind()
+	if !isIntKind(kind) && !isUintKind(kind) {
+		f.insertError(key, fmt.Errorf("enum tag of %s is set on a %s, not an integer", key, field.Type().String()), ReasonValidation)
+		return true
+	}
+	values, err := parseEnum(enum)
+	if err != nil {
+		f.insertError(key, err, ReasonValidation)
+		return true
+	}
+	var rules = f.getValidationTags(tag)
+	if _, ok := rules[types.VdRequired]; ok && !f.exists(key, tag) {
+		f.insertError(key, fmt.Errorf("field %s is required and must exist", key), ReasonNotFound)
+		return true
+	}
+	raw, skipped, err := f.resolveString(key, tag)
+	if err != nil || skipped {
+		return true
+	}
+	number, err := enumNumber(values, strings.TrimSpace(raw))
+	if err != nil {
+		f.insertError(key, fmt.Errorf("value %q of %s is not among the allowed names: %s", raw, key, enumNames(values)), ReasonValidation)
+		return true
+	}
+	if err := types.ValidateNumbers[int64](float64(number), rules); err != nil {
+		f.insertError(key, err, ReasonValidation)
+		return true
+	}
+	if isUintKind(kind) {
+		if number < 0 || field.OverflowUint(uint64(number)) {
+			f.insertError(key, fmt.Errorf("number %d of %s overflows %s", number, key, field.Type().String()), ReasonValidation)
+			return true
+		}
+		field.SetUint(uint64(number))
+		return true
+	}
+	if field.OverflowInt(number) {
+		f.insertError(key, fmt.Errorf("number %d of %s overflows %s", number, key, field.Type().String()), ReasonValidation)
+		return true
+	}
+	field.SetInt(number)
+	return true
+}
+
+// enumNumber returns the number of name, which may also be given as one of the numbers of the enum
+func enumNumber(values []enumValue, name string) (int64, error) {
+	for _, v := range values {
+		if strings.EqualFold(v.name, name) {
+			return v.number, nil
+		}
+	}
+	if n, err := strconv.ParseInt(name, 10, 64); err == nil {
+		for _, v := range values {
+			if v.number == n {
+				return n, nil
+			}
+		}
+	}
+	return 0, errors.New("unknown enum name")
+}
+
+// EnumName
+// returns the name of the value of an integer field with an enum tag, so the field can be
+// printed (e.g. exported or dumped) the way it is configured. field is the Go path of the
+// field in configObj, e.g. "Mode" or "Server.Mode". It reports false if the field has no
+// enum tag, or its value has no name.
+func EnumName(configObj any, field string) (string, bool) {
+	var v = reflect.Indirect(reflect.ValueOf(configObj))
+	var sf reflect.StructField
+	for _, name := range strings.Split(field, ".") {
+		for v.Kind() == reflect.Pointer && !v.IsNil() {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return "", false
+		}
+		var ok bool
+		if sf, ok = v.Type().FieldByName(name); !ok {
+			return "", false
+		}
+		var err error
+		if v, err = v.FieldByIndexErr(sf.Index); err != nil {
+			return "", false
+		}
+	}
+	enum, ok := sf.Tag.Lookup(EnumTagName)
+	if !ok {
+		return "", false
+	}
+	values, err := parseEnum(enum)
+	if err != nil {
+		return "", false
+	}
+	var number int64
+	switch {
+	case isIntKind(v.Kind()):
+		number = v.Int()
+	case isUintKind(v.Kind()):
+		number = int64(v.Uint())
+	default:
+		return "", false
+	}
+	for _, e := range values {
+		if e.number == number {
+			return e.name, true
+		}
+	}
+	return "", false
+}
+
+func isIntKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func isUintKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
@@ -1,14 +1,17 @@
 package configmapper
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"mosix-go-configmapper/flags"
 	"mosix-go-configmapper/inputs"
 	"mosix-go-configmapper/types"
 	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -19,11 +22,19 @@ import (
 
 const (
 	ValidationTagName = "validation"
+	WaitReadyTagName  = "waitReady"
+	PrefixTagName     = "prefix"
+	LayoutTagName     = "layout"
+	EnumTagName       = "enum"
 	ReasonRequired    = "required"
 	ReasonNotFound    = "notFound"
 	ReasonValidation  = "validation"
 
 	ErrorCritical = "critical"
+
+	// DefaultReadyTimeout bounds how long FetchKeysAndMapThem waits for the inputs
+	// which are not ready yet, when the config has fields tagged with waitReady
+	DefaultReadyTimeout = 10 * time.Second
 )
 
 // NewInputController
@@ -55,6 +66,9 @@ func NewInputController(tagName, defaultTagName string, input ...inputs.ValueInp
 		internalCacheBoolean: map[string]bool{},
 		internalCacheFloat:   map[string]float64{},
 		internalCacheUnInt:   map[string]uint64{},
+		certExpiryWarning:    DefaultCertExpiryWarning,
+		readyTimeout:         DefaultReadyTimeout,
+		closed:               make(chan struct{}),
 	}
 }
 
@@ -75,6 +89,12 @@ type InputController struct {
 
 	// in format of: map[validationName][]fieldNames
 	validationErrors map[string]map[string]string
+	// in format of: [][reason, fieldName], keeps GetAllErrors in the order errors are found
+	validationErrorsOrder [][2]string
+
+	// resolved counts the values resolved from the inputs or the defaults (and the bound
+	// flags), so mapNested can tell whether a nested struct got any value
+	resolved int
 
 	internalCacheInt     map[string]int
 	internalCacheString  map[string]string
@@ -83,6 +103,113 @@ type InputController struct {
 	internalCacheUnInt   map[string]uint64
 
 	enablePreprocessors bool
+
+	// readyTimeout bounds how long the mapping waits for the inputs which
+	// are not ready yet, when there are fields tagged with waitReady.
+	// Zero means not waiting at all.
+	readyTimeout time.Duration
+
+	// remapOnReady makes the controller map a new config object
+	// when all the inputs which were not ready become ready
+	remapOnReady bool
+	onRemap      func(configObj any)
+
+	// changeObj is the config object of RemapOnChange, the change listeners
+	// of the inputs are registered once, when it is set for the first time
+	changeObj any
+
+	// closed is closed by Close, to stop the waits running in background
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	// exposureHook is given to the flags.Flag fields when they are bound
+	exposureHook flags.ExposureHook
+
+	// decoders are the decoders registered by RegisterDecoder
+	decoders map[reflect.Type]Decoder
+
+	// variants are the types registered by RegisterVariant, in format of: map[interface]map[name]type
+	variants map[reflect.Type]map[string]reflect.Type
+
+	// warnings are the issues found while mapping which do not prevent a field from
+	// being mapped, e.g. certificates expiring soon, in the order they are found
+	warnings []string
+	// certExpiryWarning is the window before the expiry of a certificate in which it is warned about
+	certExpiryWarning time.Duration
+
+	// ctx is the context of the running FetchKeysAndMapThemContext, the inputs are
+	// looked up through inputs.ValueInputInterfaceV2 while it is set
+	ctx context.Context
+}
+
+// SetExposureHook makes the flags.Flag fields bound by FetchKeysAndMapThem
+// report their evaluations to hook, see flags.ExposureBatcher
+func (f *InputController) SetExposureHook(hook flags.ExposureHook) *InputController {
+	f.exposureHook = hook
+	return f
+}
+
+// SetReadyTimeout sets how long FetchKeysAndMapThem waits for the inputs which are not
+// ready yet when there are fields tagged with waitReady, before the fields get resolved
+// with whatever is available. It is DefaultReadyTimeout by default, zero means not waiting.
+func (f *InputController) SetReadyTimeout(d time.Duration) *InputController {
+	f.readyTimeout = d
+	return f
+}
+
+// ToggleRemapOnReady
+// if enabled, and some inputs are not ready when FetchKeysAndMapThem is called,
+// the fields are mapped with what is available (defaults included) and a new config
+// object of the same type is mapped in background when those inputs become ready.
+// The new object is given to the OnRemap callback, the mapped object is never
+// written in background. See Close for stopping the wait.
+func (f *InputController) ToggleRemapOnReady(v bool) *InputController {
+	f.remapOnReady = v
+	return f
+}
+
+// OnRemap registers a callback which is called with the new config object after a
+// background re-map is done, e.g. to swap it in through an atomic.Pointer.
+// See ToggleRemapOnReady and RemapOnChange
+func (f *InputController) OnRemap(fn func(configObj any)) *InputController {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.onRemap = fn
+	return f
+}
+
+// Close stops the background waits of the controller, e.g. the one of ToggleRemapOnReady
+// for inputs which never become ready, and the waits of FetchKeysAndMapThem.
+func (f *InputController) Close() {
+	f.closeOnce.Do(func() {
+		close(f.closed)
+	})
+}
+
+// Inputs returns the inputs of the controller in their priority order
+func (f *InputController) Inputs() []inputs.ValueInputInterface {
+	return f.input
+}
+
+// Keys returns the keys which start with prefix, found in any of the inputs
+// implementing inputs.KeyLister, sorted and without duplicates
+func (f *InputController) Keys(prefix string) []string {
+	var set = map[string]bool{}
+	var keys []string
+	for _, v := range f.input {
+		kl, ok := v.(inputs.KeyLister)
+		if !ok {
+			continue
+		}
+		for _, k := range kl.Keys(prefix) {
+			if !set[k] {
+				set[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func (f *InputController) TogglePreprocessors(v bool) *InputController {
@@ -91,6 +218,8 @@ func (f *InputController) TogglePreprocessors(v bool) *InputController {
 }
 
 func (f *InputController) GetValidationError(field string, reason string) string {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
 	if _, ok := f.validationErrors[reason]; ok {
 		if vvv, ok := f.validationErrors[reason][field]; ok {
 			return vvv
@@ -284,21 +413,110 @@ func (f *InputController) CheckStrArray(v string) ([]string, error) {
 // It uses this struct as a base map to search for the keys&values
 //
 // For getting the errors of parsing/validation, you need to call f.HasCriticalErrors()
+//
+// If there are fields tagged with waitReady, the inputs which load their values
+// asynchronously (see inputs.ReadyNotifier) are waited for once, up to SetReadyTimeout,
+// before the mapping starts. See ToggleRemapOnReady for mapping them again later.
 func (f *InputController) FetchKeysAndMapThem(configObj any) (err error) {
 	if configObj == nil {
 		err = errors.New("config object is null and cannot be mapped")
 		return
 	}
-	var configTypes = reflect.TypeOf(configObj).Elem()
-	var configValue = reflect.ValueOf(configObj)
+	f.waitInputsReady(context.Background(), reflect.TypeOf(configObj))
+	f.lock.Lock()
+	f.mapFields(configObj)
+	f.lock.Unlock()
+
+	if f.remapOnReady {
+		f.remapWhenReady(configObj)
+	}
+	return nil
+}
+
+// FetchKeysAndMapThemContext
+// is the same as FetchKeysAndMapThem, but the inputs are looked up with ctx through
+// inputs.ValueInputInterfaceV2 (v1 inputs are adapted, see inputs.AsV2). If ctx is done,
+// the mapping stops, the fields which are not mapped yet keep their values and the
+// error of ctx is returned.
+func (f *InputController) FetchKeysAndMapThemContext(ctx context.Context, configObj any) error {
+	if configObj == nil {
+		return errors.New("config object is null and cannot be mapped")
+	}
+	f.waitInputsReady(ctx, reflect.TypeOf(configObj))
+	f.lock.Lock()
+	f.ctx = ctx
+	f.mapFields(configObj)
+	f.ctx = nil
+	f.lock.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("mapping is interrupted: %w", err)
+	}
+	if f.remapOnReady {
+		f.remapWhenReady(configObj)
+	}
+	return nil
+}
+
+func (f *InputController) mapFields(configObj any) {
+	f.mapStruct(reflect.ValueOf(configObj), mapScope{claimed: map[string]string{}})
+}
+
+// maxNestingDepth stops the recursion of self-referencing nested structs
+const maxNestingDepth = 16
+
+// mapScope is the state of mapping a (nested) struct
+type mapScope struct {
+	// prefix is prepended to the key names of the fields
+	prefix string
+	// path is the Go path of the struct, used in the error messages
+	path  string
+	depth int
+	// claimed keeps the field which owns each key, in format of: map[key]fieldPath.
+	// It is shared with the embedded structs, so their fields cannot take the
+	// keys of the fields of the outer struct.
+	claimed map[string]string
+}
+
+// mapStruct maps the fields of the struct configValue points to
+func (f *InputController) mapStruct(configValue reflect.Value, scope mapScope) {
+	var configTypes = configValue.Type().Elem()
 	var fieldsCount = configTypes.NumField()
+	var collided = f.claimKeys(configTypes, scope)
 	for i := 0; i < fieldsCount; i++ {
+		if f.ctx != nil && f.ctx.Err() != nil {
+			return
+		}
 		var currentField = configTypes.Field(i)
 		tagValue := currentField.Tag
+		if childPrefix, ok := f.nestedPrefix(currentField, scope.prefix); ok {
+			if scope.depth >= maxNestingDepth {
+				f.insertError(childPrefix, fmt.Errorf("nested struct %s is deeper than %d levels", scope.path+currentField.Name, maxNestingDepth), ReasonValidation)
+				continue
+			}
+			var child = mapScope{
+				prefix:  childPrefix,
+				path:    scope.path + currentField.Name + ".",
+				depth:   scope.depth + 1,
+				claimed: map[string]string{},
+			}
+			if currentField.Anonymous {
+				child.claimed = scope.claimed
+			}
+			f.mapNested(configValue.Elem().Field(i), child)
+			continue
+		}
+		if f.mapVariant(configValue.Elem().Field(i), currentField, scope) {
+			continue
+		}
+		if f.mapPrefixedMap(configValue.Elem().Field(i), currentField, scope) {
+			continue
+		}
 		fieldKeyName := tagValue.Get(f.tagName)
-		if fieldKeyName == "" {
+		if fieldKeyName == "" || collided[i] {
 			continue
 		}
+		fieldKeyName = scope.prefix + fieldKeyName
 		var currentFieldType = currentField.Type.String()
 		var isStruct bool
 
@@ -308,14 +526,309 @@ func (f *InputController) FetchKeysAndMapThem(configObj any) (err error) {
 			}
 		}
 
-		if currentField.Type.Kind() == reflect.Map {
+		if currentField.Type.Kind() == reflect.Map || currentField.Type.Kind() == reflect.Struct {
+			isStruct = true
+		}
+
+		if currentField.Type.Kind() == reflect.Slice && f.isNestedType(currentField.Type.Elem()) {
 			isStruct = true
 		}
 
+		// registered decoders override the built-in handling of the types below
+		if f.hasRegisteredDecoder(currentField.Type) && f.mapDecoded(configValue.Elem().Field(i), fieldKeyName, &tagValue) {
+			continue
+		}
+
+		if f.bindFlag(configValue.Elem().Field(i), fieldKeyName, &tagValue) {
+			continue
+		}
+
+		if f.mapEnum(configValue.Elem().Field(i), fieldKeyName, &tagValue) {
+			continue
+		}
+
+		if f.mapCertificate(configValue.Elem().Field(i), fieldKeyName, scope.prefix, &tagValue) {
+			continue
+		}
+
+		if f.mapDecoded(configValue.Elem().Field(i), fieldKeyName, &tagValue) {
+			continue
+		}
+
+		if f.mapIndexedSlice(configValue.Elem().Field(i), fieldKeyName, currentField.Name, &tagValue, scope) {
+			continue
+		}
+
 		f.iterateOverTypes(i, currentFieldType, fieldKeyName, &tagValue, &configValue, isStruct)
 	}
+}
 
-	return nil
+// claimKeys registers the keys of the fields of a struct in scope.claimed, and returns the
+// fields whose keys are already claimed by an outer struct which embeds it. Those fields are
+// reported and not mapped, the same way an outer field shadows an embedded one in Go.
+func (f *InputController) claimKeys(t reflect.Type, scope mapScope) map[int]bool {
+	var own = map[string]string{}
+	var collided = map[int]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		var field = t.Field(i)
+		var name = field.Tag.Get(f.tagName)
+		if name == "" {
+			continue
+		}
+		if _, nested := f.nestedPrefix(field, scope.prefix); nested {
+			continue
+		}
+		var key = scope.prefix + name
+		if owner, ok := scope.claimed[key]; ok {
+			collided[i] = true
+			f.insertError(key, fmt.Errorf("key %s of field %s collides with field %s, the field is not mapped",
+				key, scope.path+field.Name, owner), ReasonValidation)
+			continue
+		}
+		if _, ok := own[key]; !ok {
+			own[key] = scope.path + field.Name
+		}
+	}
+	for k, v := range own {
+		scope.claimed[k] = v
+	}
+	return collided
+}
+
+// leafStructs are struct types which are mapped from a single key, not field by field
+var leafStructs = map[reflect.Type]bool{
+	reflect.TypeOf(url.URL{}):   true,
+	reflect.TypeOf(time.Time{}): true,
+	flagType:                    true,
+	certificateType:             true,
+}
+
+// nestedPrefix reports whether the field is a struct (or pointer to struct) which must be
+// mapped field by field, and returns the prefix of its fields. The prefix is either taken
+// from the prefix tag, or from the name of the field followed by '_'. A field having a name
+// which is found in the inputs (or has a default) keeps being decoded from a json.object:: value.
+// The fields of an embedded struct with no prefix and no name are promoted as they are.
+func (f *InputController) nestedPrefix(field reflect.StructField, prefix string) (string, bool) {
+	if !f.isNestedType(field.Type) {
+		return "", false
+	}
+	// the exported fields of an embedded struct of an unexported type can
+	// still be set, unless it is a pointer which must be allocated first
+	if !field.IsExported() && (!field.Anonymous || field.Type.Kind() == reflect.Pointer) {
+		return "", false
+	}
+	if p, ok := field.Tag.Lookup(PrefixTagName); ok {
+		return prefix + p, true
+	}
+	name := field.Tag.Get(f.tagName)
+	if name == "" {
+		return prefix, field.Anonymous
+	}
+	if f.exists(prefix+name, &field.Tag) || f.resolveDefault(&field.Tag) != "" {
+		return "", false
+	}
+	return prefix + name + "_", true
+}
+
+// isNestedType reports whether t is a struct, or a pointer to a struct,
+// which is mapped field by field
+func (f *InputController) isNestedType(t reflect.Type) bool {
+	if f.textDecoder(t) != nil {
+		return false
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !leafStructs[t]
+}
+
+// mapNested maps the fields of a nested struct. A nil pointer gets a new struct only if
+// a value is resolved for any of its fields, from the inputs or the defaults, so an
+// optional section with no keys stays nil.
+func (f *InputController) mapNested(field reflect.Value, scope mapScope) {
+	if field.Kind() == reflect.Pointer {
+		if !field.IsNil() {
+			f.mapStruct(field, scope)
+			return
+		}
+		var resolved = f.resolved
+		var ptr = reflect.New(field.Type().Elem())
+		f.mapStruct(ptr, scope)
+		if f.resolved > resolved {
+			field.Set(ptr)
+		}
+		return
+	}
+	f.mapStruct(field.Addr(), scope)
+}
+
+var flagType = reflect.TypeOf(flags.Flag{})
+
+// bindFlag binds fields of type flags.Flag and *flags.Flag to the inputs which
+// are not skipped by the field, and reports whether the field was a flag.
+// Flags are evaluated when they are used, so nothing is resolved here.
+func (f *InputController) bindFlag(field reflect.Value, key string, tag *reflect.StructTag) bool {
+	if field.Type() != flagType && field.Type() != reflect.PointerTo(flagType) {
+		return false
+	}
+	var list = make([]inputs.ValueInputInterface, 0, len(f.input))
+	for _, v := range f.input {
+		if !f.MustSkip(v.GetInputName(), tag) {
+			list = append(list, v)
+		}
+	}
+	var flag = flags.New(key, list...)
+	if f.exposureHook != nil {
+		flag.WithExposureHook(f.exposureHook)
+	}
+	if field.Kind() == reflect.Pointer {
+		field.Set(reflect.ValueOf(flag))
+	} else {
+		field.Set(reflect.ValueOf(flag).Elem())
+	}
+	// a flag is resolved when it is evaluated, so it counts as a mapped value
+	f.resolved++
+	return true
+}
+
+// notReadyInputs returns the inputs which load their values asynchronously
+// and are not ready yet
+func (f *InputController) notReadyInputs() []inputs.ReadyNotifier {
+	var list []inputs.ReadyNotifier
+	for _, v := range f.input {
+		if rn, ok := v.(inputs.ReadyNotifier); ok && !rn.IsReady() {
+			list = append(list, rn)
+		}
+	}
+	return list
+}
+
+// waitInputsReady blocks until the inputs which are not ready yet and not skipped by the
+// waitReady fields of t become ready, until readyTimeout is passed for all of them,
+// ctx is done or the controller is closed. It is called before the lock is taken.
+func (f *InputController) waitInputsReady(ctx context.Context, t reflect.Type) {
+	f.lock.RLock()
+	var timeout = f.readyTimeout
+	var pending = map[inputs.ReadyNotifier]bool{}
+	if timeout > 0 {
+		f.collectWaitingInputs(t, pending, map[reflect.Type]bool{})
+	}
+	f.lock.RUnlock()
+	if len(pending) == 0 {
+		return
+	}
+	var timer = time.NewTimer(timeout)
+	defer timer.Stop()
+	for rn := range pending {
+		select {
+		case <-rn.Ready():
+		case <-timer.C:
+			return
+		case <-ctx.Done():
+			return
+		case <-f.closed:
+			return
+		}
+	}
+}
+
+// collectWaitingInputs adds the inputs which are not ready and not skipped by a field of t
+// (or of its nested structs, slices, maps and variants) tagged with waitReady to pending
+func (f *InputController) collectWaitingInputs(t reflect.Type, pending map[inputs.ReadyNotifier]bool, seen map[reflect.Type]bool) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	if t.Kind() == reflect.Interface {
+		for _, v := range f.variants[t] {
+			f.collectWaitingInputs(v, pending, seen)
+		}
+		return
+	}
+	if t.Kind() != reflect.Struct || leafStructs[t] || f.textDecoder(t) != nil {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		var field = t.Field(i)
+		if _, ok := field.Tag.Lookup(WaitReadyTagName); ok {
+			for _, v := range f.input {
+				if rn, ok := v.(inputs.ReadyNotifier); ok && !rn.IsReady() && !f.MustSkip(v.GetInputName(), &field.Tag) {
+					pending[rn] = true
+				}
+			}
+		}
+		f.collectWaitingInputs(field.Type, pending, seen)
+	}
+}
+
+// remapWhenReady maps a new config object of the type of configObj in background,
+// after all the inputs which are not ready yet become ready. It gives up if the
+// controller is closed.
+func (f *InputController) remapWhenReady(configObj any) {
+	var pending = f.notReadyInputs()
+	if len(pending) == 0 {
+		return
+	}
+	go func() {
+		for _, v := range pending {
+			select {
+			case <-v.Ready():
+			case <-f.closed:
+				return
+			}
+		}
+		select {
+		case <-f.closed:
+			return
+		default:
+		}
+		f.remapNew(configObj)
+	}()
+}
+
+// remapNew maps a new config object of the type of configObj from scratch, dropping the
+// errors of the previous mapping, and gives it to the OnRemap callback. configObj is
+// not touched, as the application may be reading it meanwhile.
+func (f *InputController) remapNew(configObj any) {
+	var fresh = reflect.New(reflect.TypeOf(configObj).Elem()).Interface()
+	f.lock.Lock()
+	f.validationErrors = make(map[string]map[string]string)
+	f.validationErrorsOrder = nil
+	f.warnings = nil
+	f.mapFields(fresh)
+	var onRemap = f.onRemap
+	f.lock.Unlock()
+	if onRemap != nil {
+		onRemap(fresh)
+	}
+}
+
+// RemapOnChange maps a new config object of the type of configObj whenever an input
+// which implements inputs.ChangeNotifier (e.g. InputWebhook) gets updated, and gives
+// it to the OnRemap callback. configObj itself is not written. Calling it again
+// replaces configObj, each change is still remapped once.
+func (f *InputController) RemapOnChange(configObj any) *InputController {
+	f.lock.Lock()
+	var registered = f.changeObj != nil
+	f.changeObj = configObj
+	f.lock.Unlock()
+	if registered {
+		return f
+	}
+	for _, v := range f.input {
+		if cn, ok := v.(inputs.ChangeNotifier); ok {
+			cn.OnChange(func() {
+				f.lock.RLock()
+				var configObj = f.changeObj
+				f.lock.RUnlock()
+				f.remapNew(configObj)
+			})
+		}
+	}
+	return f
 }
 
 // getValidationTags searches the validation sets of tags and returns a map of found tags
@@ -343,6 +856,11 @@ func (f *InputController) getValidationTags(tagValue *reflect.StructTag) map[str
 	if vv, ok := tagValue.Lookup(types.VdProtocols); ok {
 		rules[types.VdProtocols] = vv
 	}
+	for _, name := range []string{types.VdIPv4, types.VdIPv6, types.VdPrivate, types.VdPublic, types.VdWithin} {
+		if vv, ok := tagValue.Lookup(name); ok {
+			rules[name] = vv
+		}
+	}
 	return rules
 }
 
@@ -482,6 +1000,10 @@ func (f *InputController) iterateOverTypes(fieldIndex int, currentFieldType, fie
 				mainErr = nil
 				break
 			}
+			if v < 0 {
+				// a negative number would wrap around, it is left unset as an out of range one is
+				break
+			}
 			err = types.ValidateNumbers[uint64](v, validationsRules)
 			if err != nil {
 				mainReason = ReasonNotFound
@@ -489,50 +1011,24 @@ func (f *InputController) iterateOverTypes(fieldIndex int, currentFieldType, fie
 			}
 			configValue.Elem().Field(i).SetUint(uint64(v))
 		case "[]int", "[]string", "[]float64":
-			if originalName, matched, index := CheckNameIsArrayAndGetIndex(fieldKeyName); matched && index > 0 &&
-				originalName != "" {
-				var objVal reflect.Value
-				if configValue.Elem().Field(i).IsNil() {
-					objVal = reflect.New(configValue.Elem().Field(i).Type())
-				} else {
-					objVal = configValue.Elem().Field(i)
+			// a key such as IDS[2] sets a single element of the slice
+			if originalName, matched, index := CheckNameIsArrayAndGetIndex(fieldKeyName); matched && originalName != "" {
+				var field = configValue.Elem().Field(i)
+				elem, err := f.resolveElement(fieldKeyName, field.Type().Elem(), tagValue, validationsRules)
+				if err == types.ErrNotFound {
+					break
+				} else if err != nil {
+					mainErr = err
+					mainReason = ReasonValidation
+					break
 				}
-				if currentFieldType == "[]int" {
-					v, err := f.resolveNumber(fieldKeyName, tagValue)
-					if err != nil && err != types.ErrNotFound {
-						mainReason = ReasonNotFound
-						break
-					} else if err == types.ErrNotFound {
-						mainErr = nil
-						break
-					}
-					objVal.Index(index).SetInt(int64(v))
-				} else if currentFieldType == "[]string" {
-					v, skipped, err := f.resolveString(fieldKeyName, tagValue)
-					if err != nil && err != types.ErrNotFound {
-						mainReason = ReasonNotFound
-						break
-					} else if err == types.ErrNotFound {
-						mainErr = nil
-						break
-					} else if skipped {
-						mainErr = nil
-						break
-					}
-					objVal.Index(index).SetString(v)
-				} else if currentFieldType == "[]float64" {
-					v, err := f.resolveNumber(fieldKeyName, tagValue)
-					if err != nil && err != types.ErrNotFound {
-						mainReason = ReasonNotFound
-						break
-					} else if err == types.ErrNotFound {
-						mainErr = nil
-						break
-					}
-					objVal.Index(index).SetFloat(v)
+				if field.Len() <= index {
+					grown := reflect.MakeSlice(field.Type(), index+1, index+1)
+					reflect.Copy(grown, field)
+					field.Set(grown)
 				}
-
-				configValue.Elem().Field(i).Set(objVal)
+				field.Index(index).Set(elem)
+				break
 			}
 			v, skipped, err := f.resolveString(fieldKeyName, tagValue)
 			if err != nil {
@@ -600,17 +1096,39 @@ func (f *InputController) insertError(key string, err error, validationType stri
 	if _, ok := f.validationErrors[validationType]; !ok {
 		f.validationErrors[validationType] = make(map[string]string, 0)
 	}
+	if _, ok := f.validationErrors[validationType][key]; !ok {
+		f.validationErrorsOrder = append(f.validationErrorsOrder, [2]string{validationType, key})
+	}
 	f.validationErrors[validationType][key] = err.Error()
 }
 
+func (f *InputController) insertWarning(warning string) {
+	for _, v := range f.warnings {
+		if v == warning {
+			return
+		}
+	}
+	f.warnings = append(f.warnings, warning)
+}
+
+// GetWarnings
+// returns the warnings found while mapping, in the order they are found. Warnings
+// do not prevent the fields from being mapped, e.g. a certificate which expires soon.
+func (f *InputController) GetWarnings() []string {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return append([]string(nil), f.warnings...)
+}
+
+// GetAllErrors returns the errors of the mapping in the order they are found
 func (f *InputController) GetAllErrors() []string {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
 	var validationErrs []string
 	if len(f.validationErrors) > 0 {
 		validationErrs = make([]string, 0)
-		for _, v := range f.validationErrors {
-			for _, vv := range v {
-				validationErrs = append(validationErrs, vv)
-			}
+		for _, v := range f.validationErrorsOrder {
+			validationErrs = append(validationErrs, f.validationErrors[v[0]][v[1]])
 		}
 		if len(validationErrs) == 0 {
 			validationErrs = nil
@@ -643,7 +1161,7 @@ func (f *InputController) exists(key string, field *reflect.StructTag) bool {
 		if f.MustSkip(v.GetInputName(), field) {
 			continue
 		}
-		if v.Has(key) {
+		if f.has(v, key) {
 			return true
 		}
 	}
@@ -660,13 +1178,15 @@ func (f *InputController) resolveString(key string, field *reflect.StructTag) (s
 	for _, v := range f.input {
 		if f.MustSkip(v.GetInputName(), field) {
 			continue
-		} else if vv, err := v.GetString(key); err == nil {
+		} else if vv, err := f.getString(v, key); err == nil {
+			f.resolved++
 			return vv, false, nil
 		}
 		allSkipped = false
 	}
 	if v := f.resolveDefault(field); v != "" {
 		// if a tag has default value, we return skipped as false
+		f.resolved++
 		return v, false, nil
 	}
 	if !allSkipped {
@@ -680,7 +1200,8 @@ func (f *InputController) resolveNumber(key string, field *reflect.StructTag) (f
 		if f.MustSkip(v.GetInputName(), field) {
 			continue
 		}
-		if vv, err := v.GetNumber(key); err == nil {
+		if vv, err := f.getNumber(v, key); err == nil {
+			f.resolved++
 			return vv, nil
 		}
 	}
@@ -688,6 +1209,7 @@ func (f *InputController) resolveNumber(key string, field *reflect.StructTag) (f
 		if vv, err := strconv.ParseFloat(v, 64); err != nil {
 			return 0, fmt.Errorf("field %s has default but the value cannot be validated as number, got error: %s", key, err.Error())
 		} else {
+			f.resolved++
 			return vv, nil
 		}
 	}
@@ -699,7 +1221,8 @@ func (f *InputController) resolveBoolean(key string, field *reflect.StructTag) (
 		if f.MustSkip(v.GetInputName(), field) {
 			continue
 		}
-		if vv, err := v.GetBoolean(key); err == nil {
+		if vv, err := f.getBoolean(v, key); err == nil {
+			f.resolved++
 			return vv, nil
 		}
 	}
@@ -707,12 +1230,66 @@ func (f *InputController) resolveBoolean(key string, field *reflect.StructTag) (
 		if vv, err := strconv.ParseBool(v); err != nil {
 			return false, fmt.Errorf("field %s has default but the value cannot be validated as boolean, got error: %s", key, err.Error())
 		} else {
+			f.resolved++
 			return vv, nil
 		}
 	}
 	return false, types.ErrNotFound
 }
 
+// lookup looks the key up in the input with the context of the running
+// FetchKeysAndMapThemContext, a failed lookup counts as not found
+func (f *InputController) lookup(in inputs.ValueInputInterface, key string) (inputs.Value, error) {
+	v, ok, err := inputs.AsV2(in).Lookup(f.ctx, key)
+	if err != nil {
+		return v, err
+	} else if !ok {
+		return v, types.ErrNotFound
+	}
+	return v, nil
+}
+
+func (f *InputController) has(in inputs.ValueInputInterface, key string) bool {
+	if f.ctx == nil {
+		return in.Has(key)
+	}
+	_, err := f.lookup(in, key)
+	return err == nil
+}
+
+func (f *InputController) getString(in inputs.ValueInputInterface, key string) (string, error) {
+	if f.ctx == nil {
+		return in.GetString(key)
+	}
+	v, err := f.lookup(in, key)
+	if err != nil {
+		return "", err
+	}
+	return v.AsString()
+}
+
+func (f *InputController) getNumber(in inputs.ValueInputInterface, key string) (float64, error) {
+	if f.ctx == nil {
+		return in.GetNumber(key)
+	}
+	v, err := f.lookup(in, key)
+	if err != nil {
+		return 0, err
+	}
+	return v.AsNumber()
+}
+
+func (f *InputController) getBoolean(in inputs.ValueInputInterface, key string) (bool, error) {
+	if f.ctx == nil {
+		return in.GetBoolean(key)
+	}
+	v, err := f.lookup(in, key)
+	if err != nil {
+		return false, err
+	}
+	return v.AsBoolean()
+}
+
 func (f *InputController) resolveDefault(v *reflect.StructTag) string {
 	if v == nil {
 		return ""
@@ -0,0 +1,150 @@
+package configmapper
+
+import (
+	"fmt"
+	"mosix-go-configmapper/inputs"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// mapPrefixedMap fills a map[string]T field tagged with prefix from all the keys starting with the
+// prefix, in the inputs implementing inputs.KeyLister. The prefix is stripped from the keys to get
+// the keys of the map, and the values are resolved in the priority order of the inputs, so the map
+// is the merge of all the inputs. For struct values, the fields of the struct are read from
+// PREFIX<mapKey>_FIELD. It reports whether the field was handled.
+func (f *InputController) mapPrefixedMap(field reflect.Value, structField reflect.StructField, scope mapScope) bool {
+	var tag = structField.Tag
+	prefix, ok := tag.Lookup(PrefixTagName)
+	if !ok || !structField.IsExported() || field.Kind() != reflect.Map || field.Type().Key().Kind() != reflect.String {
+		return false
+	}
+	// a value given for the name of the field keeps being decoded from a json.object:: value
+	if name := tag.Get(f.tagName); name != "" && (f.exists(scope.prefix+name, &tag) || f.resolveDefault(&tag) != "") {
+		return false
+	}
+	prefix = scope.prefix + prefix
+	var keys = f.listKeys(prefix, &tag)
+	if len(keys) == 0 {
+		return true
+	}
+
+	var valueType = field.Type().Elem()
+	var result = reflect.MakeMap(field.Type())
+	if f.isNestedType(valueType) {
+		var suffixes = f.leafKeys(valueType, 0)
+		for _, mapKey := range structMapKeys(keys, prefix, suffixes) {
+			if scope.depth >= maxNestingDepth {
+				f.insertError(prefix, fmt.Errorf("nested struct %s is deeper than %d levels", scope.path+structField.Name, maxNestingDepth), ReasonValidation)
+				return true
+			}
+			var value = reflect.New(valueType).Elem()
+			f.mapNested(value, mapScope{
+				prefix:  prefix + mapKey + "_",
+				path:    fmt.Sprintf("%s%s[%s].", scope.path, structField.Name, mapKey),
+				depth:   scope.depth + 1,
+				claimed: map[string]string{},
+			})
+			result.SetMapIndex(reflect.ValueOf(mapKey).Convert(field.Type().Key()), value)
+		}
+		field.Set(result)
+		return true
+	}
+
+	if !f.isElementType(valueType) {
+		f.insertError(prefix, fmt.Errorf("type %s is not supported as a value of a prefixed map", valueType.String()), ReasonValidation)
+		return true
+	}
+	var rules = f.getValidationTags(&tag)
+	for _, key := range keys {
+		v, err := f.resolveElement(key, valueType, &tag, rules)
+		if err != nil {
+			f.insertError(key, err, ReasonValidation)
+			continue
+		}
+		result.SetMapIndex(reflect.ValueOf(strings.TrimPrefix(key, prefix)).Convert(field.Type().Key()), v)
+	}
+	field.Set(result)
+	return true
+}
+
+// listKeys returns the keys starting with prefix in the inputs which
+// implement inputs.KeyLister and are not skipped by the field
+func (f *InputController) listKeys(prefix string, tag *reflect.StructTag) []string {
+	var set = map[string]bool{}
+	var keys []string
+	for _, v := range f.input {
+		kl, ok := v.(inputs.KeyLister)
+		if !ok || f.MustSkip(v.GetInputName(), tag) {
+			continue
+		}
+		for _, k := range kl.Keys(prefix) {
+			if k != prefix && !set[k] {
+				set[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// leafKeys returns the keys of the fields of the struct type t, relative to the struct,
+// the fields of its nested and embedded structs included
+func (f *InputController) leafKeys(t reflect.Type, depth int) []string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var keys []string
+	if depth >= maxNestingDepth {
+		return keys
+	}
+	for i := 0; i < t.NumField(); i++ {
+		var field = t.Field(i)
+		var name = field.Tag.Get(f.tagName)
+		if f.isNestedType(field.Type) {
+			var childPrefix string
+			if p, ok := field.Tag.Lookup(PrefixTagName); ok {
+				childPrefix = p
+			} else if name != "" {
+				childPrefix = name + "_"
+			} else if !field.Anonymous {
+				continue
+			}
+			for _, k := range f.leafKeys(field.Type, depth+1) {
+				keys = append(keys, childPrefix+k)
+			}
+			continue
+		}
+		if name != "" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// structMapKeys finds the keys of a map of structs out of the keys in form of PREFIX<mapKey>_FIELD,
+// suffixes are the keys of the fields of the struct. The longest suffix wins, so a map key can
+// contain '_' as well.
+func structMapKeys(keys []string, prefix string, suffixes []string) []string {
+	sort.Slice(suffixes, func(i, j int) bool {
+		return len(suffixes[i]) > len(suffixes[j])
+	})
+	var set = map[string]bool{}
+	var list []string
+	for _, key := range keys {
+		var rest = strings.TrimPrefix(key, prefix)
+		for _, suffix := range suffixes {
+			mapKey, ok := strings.CutSuffix(rest, "_"+suffix)
+			if ok && mapKey != "" {
+				if !set[mapKey] {
+					set[mapKey] = true
+					list = append(list, mapKey)
+				}
+				break
+			}
+		}
+	}
+	sort.Strings(list)
+	return list
+}
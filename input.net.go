@@ -0,0 +1,45 @@
+package configmapper
+
+import (
+	"mosix-go-configmapper/types"
+	"net"
+	"net/netip"
+	"reflect"
+)
+
+var (
+	addrType         = reflect.TypeOf(netip.Addr{})
+	prefixType       = reflect.TypeOf(netip.Prefix{})
+	addrPortType     = reflect.TypeOf(netip.AddrPort{})
+	hardwareAddrType = reflect.TypeOf(net.HardwareAddr{})
+)
+
+func decodeAddr(raw string, tag reflect.StructTag) (any, error) {
+	return netip.ParseAddr(raw)
+}
+
+// decodePrefix decodes a CIDR, e.g. 10.0.0.0/8, a single address is not accepted
+func decodePrefix(raw string, tag reflect.StructTag) (any, error) {
+	return netip.ParsePrefix(raw)
+}
+
+// decodeAddrPort decodes an ip:port value, e.g. 10.0.0.1:8080 or [::1]:8080
+func decodeAddrPort(raw string, tag reflect.StructTag) (any, error) {
+	return netip.ParseAddrPort(raw)
+}
+
+func decodeHardwareAddr(raw string, tag reflect.StructTag) (any, error) {
+	return net.ParseMAC(raw)
+}
+
+func (f *InputController) validateAddr(v reflect.Value, tag reflect.StructTag) error {
+	return types.ValidateAddr(v.Interface().(netip.Addr), f.getValidationTags(&tag))
+}
+
+func (f *InputController) validatePrefix(v reflect.Value, tag reflect.StructTag) error {
+	return types.ValidatePrefix(v.Interface().(netip.Prefix), f.getValidationTags(&tag))
+}
+
+func (f *InputController) validateAddrPort(v reflect.Value, tag reflect.StructTag) error {
+	return types.ValidateAddr(v.Interface().(netip.AddrPort).Addr(), f.getValidationTags(&tag))
+}
@@ -0,0 +1,187 @@
+package configmapper
+
+import (
+	"fmt"
+	"mosix-go-configmapper/types"
+	"reflect"
+)
+
+// indexedKey returns the key of the element at index of a slice, i.e. KEY[index]
+func indexedKey(key string, index int) string {
+	return fmt.Sprintf("%s[%d]", key, index)
+}
+
+// indexedPrefix returns the prefix of the fields of the struct element at index of a slice, i.e. KEY_index_
+func indexedPrefix(key string, index int) string {
+	return fmt.Sprintf("%s_%d_", key, index)
+}
+
+// mapIndexedSlice assembles a slice field from indexed keys when the key of the field itself
+// is not found. The elements of scalar slices are read from KEY[0], KEY[1], ... and the fields
+// of struct elements from KEY_0_FIELD, KEY_1_FIELD, ... The length of the slice is the number
+// of contiguous indices found, starting from 0. It reports whether the field was assembled.
+func (f *InputController) mapIndexedSlice(field reflect.Value, key, fieldName string, tag *reflect.StructTag, scope mapScope) bool {
+	if field.Kind() != reflect.Slice || f.exists(key, tag) {
+		return false
+	}
+	var elemType = field.Type().Elem()
+	if f.isNestedType(elemType) {
+		var count int
+		for f.hasStructKeys(elemType, indexedPrefix(key, count), 0) {
+			count++
+		}
+		if count == 0 {
+			return false
+		}
+		if scope.depth >= maxNestingDepth {
+			f.insertError(key, fmt.Errorf("nested struct %s is deeper than %d levels", scope.path+fieldName, maxNestingDepth), ReasonValidation)
+			return true
+		}
+		var slice = reflect.MakeSlice(field.Type(), count, count)
+		for i := 0; i < count; i++ {
+			f.mapNested(slice.Index(i), mapScope{
+				prefix:  indexedPrefix(key, i),
+				path:    fmt.Sprintf("%s%s[%d].", scope.path, fieldName, i),
+				depth:   scope.depth + 1,
+				claimed: map[string]string{},
+			})
+		}
+		field.Set(slice)
+		return true
+	}
+
+	if !f.isElementType(elemType) || !f.exists(indexedKey(key, 0), tag) {
+		return false
+	}
+	var rules = f.getValidationTags(tag)
+	var slice = reflect.MakeSlice(field.Type(), 0, 0)
+	for i := 0; f.exists(indexedKey(key, i), tag); i++ {
+		v, err := f.resolveElement(indexedKey(key, i), elemType, tag, rules)
+		if err != nil {
+			f.insertError(indexedKey(key, i), err, ReasonValidation)
+			v = reflect.Zero(elemType)
+		}
+		slice = reflect.Append(slice, v)
+	}
+	field.Set(slice)
+	return true
+}
+
+// hasStructKeys reports whether any field of the struct type t, or of its
+// nested structs, is found in the inputs with the given prefix
+func (f *InputController) hasStructKeys(t reflect.Type, prefix string, depth int) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if depth >= maxNestingDepth {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		var field = t.Field(i)
+		if childPrefix, ok := f.nestedPrefix(field, prefix); ok {
+			if f.hasStructKeys(field.Type, childPrefix, depth+1) {
+				return true
+			}
+			continue
+		}
+		if name := field.Tag.Get(f.tagName); name != "" && f.exists(prefix+name, &field.Tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// isElementType reports whether t can be an element of a slice given by
+// indexed keys, or a value of a prefixed map, not counting structs
+func (f *InputController) isElementType(t reflect.Type) bool {
+	return isScalarKind(t.Kind()) || f.textDecoder(t) != nil
+}
+
+func isScalarKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// resolveElement resolves the key as a value of the scalar type t and validates it
+// with the rules of the slice field, returns types.ErrNotFound if the key is not found
+func (f *InputController) resolveElement(key string, t reflect.Type, tag *reflect.StructTag,
+	rules map[string]string) (reflect.Value, error) {
+	var elem = reflect.New(t).Elem()
+	if decode := f.textDecoder(t); decode != nil {
+		raw, skipped, err := f.resolveString(key, tag)
+		if err != nil {
+			return elem, err
+		} else if skipped {
+			return elem, types.ErrNotFound
+		}
+		return f.decodeString(raw, key, t, *tag, rules, decode)
+	}
+	switch t.Kind() {
+	case reflect.String:
+		v, skipped, err := f.resolveString(key, tag)
+		if err != nil {
+			return elem, err
+		} else if skipped {
+			return elem, types.ErrNotFound
+		}
+		v, _, err = f.CheckStringPreProcessors(v, rules)
+		if err != nil {
+			return elem, err
+		}
+		if err = types.ValidateStrings(v, rules); err != nil {
+			return elem, err
+		}
+		elem.SetString(v)
+	case reflect.Bool:
+		v, err := f.resolveBoolean(key, tag)
+		if err != nil {
+			return elem, err
+		}
+		elem.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := f.resolveNumber(key, tag)
+		if err != nil {
+			return elem, err
+		}
+		if err = types.ValidateNumbers[int64](v, rules); err != nil {
+			return elem, err
+		}
+		if elem.OverflowInt(int64(v)) {
+			return elem, fmt.Errorf("number %v of key %s overflows %s", v, key, t.String())
+		}
+		elem.SetInt(int64(v))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := f.resolveNumber(key, tag)
+		if err != nil {
+			return elem, err
+		}
+		if v < 0 {
+			return elem, fmt.Errorf("number %v of key %s cannot be negative", v, key)
+		}
+		if err = types.ValidateNumbers[uint64](v, rules); err != nil {
+			return elem, err
+		}
+		if elem.OverflowUint(uint64(v)) {
+			return elem, fmt.Errorf("number %v of key %s overflows %s", v, key, t.String())
+		}
+		elem.SetUint(uint64(v))
+	case reflect.Float32, reflect.Float64:
+		v, err := f.resolveNumber(key, tag)
+		if err != nil {
+			return elem, err
+		}
+		if err = types.ValidateNumbers[float64](v, rules); err != nil {
+			return elem, err
+		}
+		elem.SetFloat(v)
+	default:
+		return elem, fmt.Errorf("type %s is not supported as an element of a slice", t.String())
+	}
+	return elem, nil
+}
@@ -0,0 +1,48 @@
+//go:build go1.21
+
+package configmapper
+
+import (
+	"fmt"
+	"log/slog"
+	"mosix-go-configmapper/types"
+	"reflect"
+	"strconv"
+)
+
+// log/slog comes with Go 1.21, levelType is nil before it, so slog.Level fields
+// are mapped only by the toolchains which have it
+var levelType = reflect.TypeOf(slog.Level(0))
+
+// decodeLevel decodes a log level by its name with an optional offset, e.g. warn+2,
+// case-insensitive, or by its number, e.g. -4
+func decodeLevel(raw string, tag reflect.StructTag) (any, error) {
+	if n, err := strconv.Atoi(raw); err == nil {
+		return slog.Level(n), nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(raw)); err != nil {
+		return nil, fmt.Errorf("%q is not a log level", raw)
+	}
+	return level, nil
+}
+
+// validateLevel validates the log levels of key as numbers, the rules may name
+// the levels as well, e.g. range:"debug..warn"
+func (f *InputController) validateLevel(key string) func(v reflect.Value, tag reflect.StructTag) error {
+	return func(v reflect.Value, tag reflect.StructTag) error {
+		var rules = convertRules(f.getValidationTags(&tag), func(s string) (int64, bool) {
+			level, err := decodeLevel(s, tag)
+			if err != nil {
+				return 0, false
+			}
+			return int64(level.(slog.Level)), true
+		})
+		if types.ValidateNumbers[int64](float64(v.Int()), rules) != nil {
+			return ruleError("level", key, v.Int(), rules, func(n int64) string {
+				return slog.Level(n).String()
+			})
+		}
+		return nil
+	}
+}
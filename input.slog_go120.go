@@ -0,0 +1,19 @@
+//go:build !go1.21
+
+package configmapper
+
+import (
+	"errors"
+	"reflect"
+)
+
+// levelType is nil as log/slog comes with Go 1.21, so no field is mapped as a log level
+var levelType reflect.Type
+
+func decodeLevel(raw string, tag reflect.StructTag) (any, error) {
+	return nil, errors.New("log levels need Go 1.21")
+}
+
+func (f *InputController) validateLevel(key string) func(v reflect.Value, tag reflect.StructTag) error {
+	return nil
+}
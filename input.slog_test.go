@@ -0,0 +1,43 @@
+//go:build go1.21
+
+package configmapper
+
+import (
+	"log/slog"
+	"mosix-go-configmapper/inputs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogLevelFields(t *testing.T) {
+	type SampleConfig struct {
+		LogLevel   slog.Level   `name:"LOG_LEVEL" default:"info" range:"debug..error"`
+		AuditLevel slog.Level   `name:"AUDIT_LEVEL"`
+		Levels     []slog.Level `name:"LEVELS"`
+		NoisyLevel slog.Level   `name:"NOISY_LEVEL" range:"info..error"`
+		QuietLevel slog.Level   `name:"QUIET_LEVEL" set:"warn,error"`
+		BadLevel   slog.Level   `name:"BAD_LEVEL"`
+	}
+	var cnf = &SampleConfig{}
+	inputMock := inputs.NewInputMock()
+	inputMock.KeysStr["AUDIT_LEVEL"] = "WARN+2"
+	inputMock.KeysStr["LEVELS"] = "array.string::debug,-2,error"
+	inputMock.KeysStr["NOISY_LEVEL"] = "debug"
+	inputMock.KeysStr["QUIET_LEVEL"] = "info+1"
+	inputMock.KeysStr["BAD_LEVEL"] = "loud"
+
+	inp := NewInputController("name", "default", inputMock).TogglePreprocessors(true)
+	assert.NoError(t, inp.FetchKeysAndMapThem(cnf))
+	assert.Equal(t, slog.LevelInfo, cnf.LogLevel)
+	assert.Equal(t, slog.LevelWarn+2, cnf.AuditLevel)
+	assert.Equal(t, []slog.Level{slog.LevelDebug, -2, slog.LevelError}, cnf.Levels)
+	assert.Zero(t, cnf.NoisyLevel)
+	assert.Zero(t, cnf.QuietLevel)
+	assert.Zero(t, cnf.BadLevel)
+	assert.ElementsMatch(t, []string{
+		"level DEBUG of NOISY_LEVEL is outside of the range INFO..ERROR",
+		"level INFO+1 of QUIET_LEVEL is not among the allowed set WARN,ERROR",
+		`failed to decode BAD_LEVEL as slog.Level: "loud" is not a log level`,
+	}, inp.GetAllErrors())
+}
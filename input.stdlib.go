@@ -0,0 +1,108 @@
+package configmapper
+
+import (
+	"fmt"
+	"mosix-go-configmapper/types"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	fileModeType  = reflect.TypeOf(os.FileMode(0))
+	urlValuesType = reflect.TypeOf(url.Values{})
+)
+
+// decodeFileMode decodes the permission bits of a file mode in octal, e.g. 0640, 640 or 0o640
+func decodeFileMode(raw string, tag reflect.StructTag) (any, error) {
+	var digits = strings.TrimPrefix(strings.ToLower(raw), "0o")
+	n, err := strconv.ParseUint(digits, 8, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not an octal file mode", raw)
+	}
+	if n > 0o7777 {
+		return nil, fmt.Errorf("file mode %s is not in 0..07777", raw)
+	}
+	var mode = os.FileMode(n & 0o777)
+	if n&0o4000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if n&0o2000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if n&0o1000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode, nil
+}
+
+// decodeURLValues decodes a query string, with or without the leading '?', e.g. a=1&b=2&b=3
+func decodeURLValues(raw string, tag reflect.StructTag) (any, error) {
+	return url.ParseQuery(strings.TrimPrefix(raw, "?"))
+}
+
+// validateFileMode validates the permission bits of the file modes of key as numbers,
+// the rules are in octal as the values are, e.g. range:"0600..0644"
+func (f *InputController) validateFileMode(key string) func(v reflect.Value, tag reflect.StructTag) error {
+	return func(v reflect.Value, tag reflect.StructTag) error {
+		var rules = convertRules(f.getValidationTags(&tag), func(s string) (int64, bool) {
+			n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(s), "0o"), 8, 32)
+			return int64(n), err == nil
+		})
+		var perm = int64(v.Interface().(os.FileMode).Perm())
+		if types.ValidateNumbers[int64](float64(perm), rules) != nil {
+			return ruleError("file mode", key, perm, rules, func(n int64) string {
+				return fmt.Sprintf("%04o", n)
+			})
+		}
+		return nil
+	}
+}
+
+// ruleError describes the numeric rule of key which v fails, with v and the values of the
+// rule formatted by format, e.g. file mode 0666 of OPEN_MODE is outside of the range 0600..0644
+func ruleError(kind, key string, v int64, rules map[string]string, format func(n int64) string) error {
+	var formatRule = func(rule, sep string) string {
+		var values = strings.Split(rule, sep)
+		for i, s := range values {
+			if n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64); err == nil {
+				values[i] = format(n)
+			}
+		}
+		return strings.Join(values, sep)
+	}
+	if rule, ok := rules[types.VdSet]; ok {
+		return fmt.Errorf("%s %s of %s is not among the allowed set %s", kind, format(v), key, formatRule(rule, ","))
+	}
+	for _, name := range []string{types.VdRange, types.VdGt, types.VdLt} {
+		if rule, ok := rules[name]; ok {
+			return fmt.Errorf("%s %s of %s is outside of the range %s", kind, format(v), key, formatRule(rule, ".."))
+		}
+	}
+	return fmt.Errorf("%s %s of %s is not valid", kind, format(v), key)
+}
+
+// convertRules rewrites the values of the numeric rules (set, range, greaterThan and lessThan)
+// by convert, the values which convert does not accept are kept as they are
+func convertRules(rules map[string]string, convert func(s string) (int64, bool)) map[string]string {
+	for _, name := range []string{types.VdSet, types.VdRange, types.VdGt, types.VdLt} {
+		rule, ok := rules[name]
+		if !ok {
+			continue
+		}
+		var sep = ","
+		if strings.Contains(rule, "..") {
+			sep = ".."
+		}
+		var values = strings.Split(rule, sep)
+		for i, v := range values {
+			if n, ok := convert(strings.TrimSpace(v)); ok {
+				values[i] = strconv.FormatInt(n, 10)
+			}
+		}
+		rules[name] = strings.Join(values, sep)
+	}
+	return rules
+}
@@ -0,0 +1,98 @@
+package configmapper
+
+import (
+	"fmt"
+	"mosix-go-configmapper/types"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	locationType = reflect.TypeOf(&time.Location{})
+	monthType    = reflect.TypeOf(time.Month(0))
+	weekdayType  = reflect.TypeOf(time.Weekday(0))
+)
+
+// timeLayouts are the layouts of the time package which can be given by name in the layout tag
+var timeLayouts = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+	"Kitchen":     time.Kitchen,
+}
+
+// timeLayout returns the layout tag of a field, time.RFC3339 by default
+func timeLayout(tag reflect.StructTag) string {
+	layout, ok := tag.Lookup(LayoutTagName)
+	if !ok || layout == "" {
+		return time.RFC3339
+	}
+	if v, ok := timeLayouts[layout]; ok {
+		return v
+	}
+	return layout
+}
+
+func decodeTime(raw string, tag reflect.StructTag) (any, error) {
+	return time.Parse(timeLayout(tag), raw)
+}
+
+// decodeLocation loads a location by its IANA name, e.g. Europe/Amsterdam
+func decodeLocation(raw string, tag reflect.StructTag) (any, error) {
+	return time.LoadLocation(raw)
+}
+
+// decodeMonth decodes a month by its number (1..12), its name or the first 3 letters of it
+func decodeMonth(raw string, tag reflect.StructTag) (any, error) {
+	if n, err := strconv.Atoi(raw); err == nil {
+		if n < 1 || n > 12 {
+			return nil, fmt.Errorf("month %d is not in 1..12", n)
+		}
+		return time.Month(n), nil
+	}
+	for m := time.January; m <= time.December; m++ {
+		if matchesName(raw, m.String()) {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("%q is not a month", raw)
+}
+
+// decodeWeekday decodes a weekday by its number (0..6, from Sunday), its name or the first 3 letters of it
+func decodeWeekday(raw string, tag reflect.StructTag) (any, error) {
+	if n, err := strconv.Atoi(raw); err == nil {
+		if n < 0 || n > 6 {
+			return nil, fmt.Errorf("weekday %d is not in 0..6", n)
+		}
+		return time.Weekday(n), nil
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if matchesName(raw, d.String()) {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("%q is not a weekday", raw)
+}
+
+// matchesName reports whether v is name, or the first 3 letters of it, case-insensitive
+func matchesName(v, name string) bool {
+	return strings.EqualFold(v, name) || strings.EqualFold(v, name[:3])
+}
+
+func (f *InputController) validateTime(v reflect.Value, tag reflect.StructTag) error {
+	return types.ValidateTimes(v.Interface().(time.Time), timeLayout(tag), f.getValidationTags(&tag))
+}
+
+// validateCalendarNumber validates months and weekdays by their numbers
+func (f *InputController) validateCalendarNumber(v reflect.Value, tag reflect.StructTag) error {
+	return types.ValidateNumbers[int64](float64(v.Int()), f.getValidationTags(&tag))
+}
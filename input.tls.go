@@ -0,0 +1,372 @@
+package configmapper
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"mosix-go-configmapper/types"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// TLSKeyTagName is the tag of a tls.Certificate field which names the key of its
+	// private key, the private key is looked for in the certificate's PEM if it is not set
+	TLSKeyTagName = "tlsKey"
+	// TLSKeyPasswordTagName is the tag of a tls.Certificate field which names
+	// the key of the password of its encrypted private key
+	TLSKeyPasswordTagName = "tlsKeyPassword"
+
+	// DefaultCertExpiryWarning is the window before the expiry of a certificate
+	// in which a warning is reported, see SetCertExpiryWarning
+	DefaultCertExpiryWarning = 30 * 24 * time.Hour
+
+	// maxPBKDF2Iterations bounds the iterations of the key derivation of an encrypted
+	// private key, so a crafted key cannot stall the mapping
+	maxPBKDF2Iterations = 10_000_000
+)
+
+var (
+	certificateType      = reflect.TypeOf(tls.Certificate{})
+	certPoolType         = reflect.TypeOf(&x509.CertPool{})
+	x509CertificatesType = reflect.TypeOf([]*x509.Certificate{})
+)
+
+// TLSConfig
+// is a set of TLS settings to be nested in a config struct, e.g.
+// a field of type TLSConfig with a prefix tag of SERVER_TLS_ loads SERVER_TLS_CERT,
+// SERVER_TLS_KEY, SERVER_TLS_CA, etc. Config builds a *tls.Config out of it.
+type TLSConfig struct {
+	Certificate        tls.Certificate `name:"CERT" tlsKey:"KEY" tlsKeyPassword:"KEY_PASSWORD"`
+	RootCAs            *x509.CertPool  `name:"CA"`
+	ClientCAs          *x509.CertPool  `name:"CLIENT_CA"`
+	ServerName         string          `name:"SERVER_NAME"`
+	MinVersion         string          `name:"MIN_VERSION" default:"1.2" set:"1.0,1.1,1.2,1.3"`
+	InsecureSkipVerify bool            `name:"INSECURE_SKIP_VERIFY" default:"false"`
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Config builds a *tls.Config out of the settings. Client certificates
+// are required and verified if ClientCAs is set.
+func (c *TLSConfig) Config() *tls.Config {
+	var conf = &tls.Config{
+		RootCAs:            c.RootCAs,
+		ClientCAs:          c.ClientCAs,
+		ServerName:         c.ServerName,
+		MinVersion:         tlsVersions[c.MinVersion],
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if len(c.Certificate.Certificate) > 0 {
+		conf.Certificates = []tls.Certificate{c.Certificate}
+	}
+	if c.ClientCAs != nil {
+		conf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return conf
+}
+
+// SetCertExpiryWarning
+// sets the window before the expiry of the mapped certificates in which a warning is
+// reported by GetWarnings, DefaultCertExpiryWarning by default. Expired certificates
+// are always reported.
+func (f *InputController) SetCertExpiryWarning(d time.Duration) *InputController {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.certExpiryWarning = d
+	return f
+}
+
+// readPEM returns raw if it is a PEM, or reads the PEM of the file raw refers to by
+// file:: syntax. Escaped new lines ("\n") are accepted, so a PEM can be given in one
+// line, e.g. in an environment variable.
+func readPEM(raw string) ([]byte, error) {
+	if path, ok := strings.CutPrefix(raw, SyntaxFile); ok {
+		return os.ReadFile(path)
+	}
+	if !strings.Contains(raw, "\n") {
+		raw = strings.ReplaceAll(raw, `\n`, "\n")
+	}
+	if !strings.Contains(raw, "-----BEGIN") {
+		return nil, fmt.Errorf("value is neither a PEM nor a %s path", SyntaxFile)
+	}
+	return []byte(raw), nil
+}
+
+// parseCertificates parses all the certificates of a PEM
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate is found in the PEM")
+	}
+	return certs, nil
+}
+
+func decodeCertificates(raw string, tag reflect.StructTag) (any, error) {
+	data, err := readPEM(raw)
+	if err != nil {
+		return nil, err
+	}
+	return parseCertificates(data)
+}
+
+func decodeCertPool(raw string, tag reflect.StructTag) (any, error) {
+	data, err := readPEM(raw)
+	if err != nil {
+		return nil, err
+	}
+	var pool = x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("no certificate is found in the PEM")
+	}
+	return pool, nil
+}
+
+// validateCertificates reports the certificates which expire soon as warnings
+func (f *InputController) validateCertificates(key string) func(v reflect.Value, tag reflect.StructTag) error {
+	return func(v reflect.Value, tag reflect.StructTag) error {
+		for _, cert := range v.Interface().([]*x509.Certificate) {
+			f.checkExpiry(key, cert)
+		}
+		return nil
+	}
+}
+
+// checkExpiry inserts a warning for key if cert is expired or expires within the
+// window set by SetCertExpiryWarning
+func (f *InputController) checkExpiry(key string, cert *x509.Certificate) {
+	var left = time.Until(cert.NotAfter)
+	switch {
+	case left <= 0:
+		f.insertWarning(fmt.Sprintf("certificate %q of %s has expired at %s",
+			cert.Subject.CommonName, key, cert.NotAfter.Format(time.RFC3339)))
+	case left <= f.certExpiryWarning:
+		f.insertWarning(fmt.Sprintf("certificate %q of %s expires at %s",
+			cert.Subject.CommonName, key, cert.NotAfter.Format(time.RFC3339)))
+	}
+}
+
+// mapCertificate maps a tls.Certificate field from the PEM of its key, and the private key
+// from the key named by the tlsKey tag (or the same PEM if that key is not found), decrypted by the password named
+// by the tlsKeyPassword tag. The names of the tags are prefixed as the field's key is.
+// It reports whether the field was handled.
+func (f *InputController) mapCertificate(field reflect.Value, key, prefix string, tag *reflect.StructTag) bool {
+	if field.Type() != certificateType {
+		return false
+	}
+	if _, ok := f.getValidationTags(tag)[types.VdRequired]; ok && !f.exists(key, tag) {
+		f.insertError(key, fmt.Errorf("field %s is required and must exist", key), ReasonNotFound)
+		return true
+	}
+	raw, skipped, err := f.resolveString(key, tag)
+	if err != nil || skipped {
+		return true
+	}
+	certPEM, err := readPEM(raw)
+	if err != nil {
+		f.insertError(key, fmt.Errorf("failed to read certificate of %s: %s", key, err.Error()), ReasonValidation)
+		return true
+	}
+	// the keys of the private key and its password are resolved from the same inputs, with no default
+	var keyTag = reflect.StructTag(fmt.Sprintf(`skips:%q`, tag.Get("skips")))
+	var keyPEM = certPEM
+	if name, ok := tag.Lookup(TLSKeyTagName); ok {
+		if rawKey, _, err := f.resolveString(prefix+name, &keyTag); err == nil && rawKey != "" {
+			if keyPEM, err = readPEM(rawKey); err != nil {
+				f.insertError(prefix+name, fmt.Errorf("failed to read private key of %s: %s", key, err.Error()), ReasonValidation)
+				return true
+			}
+		}
+	}
+	var password string
+	if name, ok := tag.Lookup(TLSKeyPasswordTagName); ok {
+		password, _, _ = f.resolveString(prefix+name, &keyTag)
+	}
+	keyPEM, err = decryptPrivateKeys(keyPEM, []byte(password))
+	if err != nil {
+		f.insertError(key, fmt.Errorf("failed to decrypt private key of %s: %s", key, err.Error()), ReasonValidation)
+		return true
+	}
+	// X509KeyPair checks that the private key matches the certificate
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		f.insertError(key, fmt.Errorf("failed to load certificate %s: %s", key, err.Error()), ReasonValidation)
+		return true
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		f.insertError(key, fmt.Errorf("failed to load certificate %s: %s", key, err.Error()), ReasonValidation)
+		return true
+	}
+	f.checkExpiry(key, cert.Leaf)
+	field.Set(reflect.ValueOf(cert))
+	return true
+}
+
+// decryptPrivateKeys returns data with its encrypted private keys decrypted, both the legacy
+// encrypted PEM blocks (Proc-Type: 4,ENCRYPTED) and the PKCS#8 ones (ENCRYPTED PRIVATE KEY)
+func decryptPrivateKeys(data, password []byte) ([]byte, error) {
+	var out bytes.Buffer
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		//nolint:staticcheck // legacy encrypted PEM blocks are still in use
+		var legacy = x509.IsEncryptedPEMBlock(block)
+		if (legacy || block.Type == "ENCRYPTED PRIVATE KEY") && len(password) == 0 {
+			return nil, errors.New("private key is encrypted and no password is given")
+		}
+		switch {
+		case legacy:
+			//nolint:staticcheck // legacy encrypted PEM blocks are still in use
+			der, err := x509.DecryptPEMBlock(block, password)
+			if err != nil {
+				return nil, err
+			}
+			block = &pem.Block{Type: block.Type, Bytes: der}
+		case block.Type == "ENCRYPTED PRIVATE KEY":
+			der, err := decryptPKCS8(block.Bytes, password)
+			if err != nil {
+				return nil, err
+			}
+			block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+		}
+		if err := pem.Encode(&out, block); err != nil {
+			return nil, err
+		}
+	}
+	return out.Bytes(), nil
+}
+
+var (
+	oidPBES2      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// encryptedPrivateKeyInfo, pbes2Params and pbkdf2Params are the structures of RFC 5208 and RFC 8018
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// decryptPKCS8 decrypts a PKCS#8 private key encrypted by PBES2, with PBKDF2 and AES-CBC,
+// which is what openssl and most of the tools produce by default
+func decryptPKCS8(der, password []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, err
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("encryption %s is not supported, only PBES2 is", info.Algorithm.Algorithm)
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, err
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("key derivation %s is not supported, only PBKDF2 is", params.KeyDerivationFunc.Algorithm)
+	}
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, err
+	}
+	if kdf.IterationCount < 1 || kdf.IterationCount > maxPBKDF2Iterations {
+		return nil, fmt.Errorf("PBKDF2 iterations %d are not in 1..%d", kdf.IterationCount, maxPBKDF2Iterations)
+	}
+	var prf func() hash.Hash
+	switch {
+	case len(kdf.PRF.Algorithm) == 0, kdf.PRF.Algorithm.Equal(oidHMACSHA1):
+		prf = sha1.New
+	case kdf.PRF.Algorithm.Equal(oidHMACSHA256):
+		prf = sha256.New
+	default:
+		return nil, fmt.Errorf("PBKDF2 function %s is not supported", kdf.PRF.Algorithm)
+	}
+	var keyLen int
+	switch {
+	case params.EncryptionScheme.Algorithm.Equal(oidAES128CBC):
+		keyLen = 16
+	case params.EncryptionScheme.Algorithm.Equal(oidAES192CBC):
+		keyLen = 24
+	case params.EncryptionScheme.Algorithm.Equal(oidAES256CBC):
+		keyLen = 32
+	default:
+		return nil, fmt.Errorf("cipher %s is not supported, only AES-CBC is", params.EncryptionScheme.Algorithm)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(pbkdf2.Key(password, kdf.Salt, kdf.IterationCount, keyLen, prf))
+	if err != nil {
+		return nil, err
+	}
+	var data = info.EncryptedData
+	if len(iv) != block.BlockSize() || len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, errors.New("encrypted private key is malformed")
+	}
+	var plain = make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+	// a wrong password is caught by the padding in most cases, and by the parser otherwise
+	var pad = int(plain[len(plain)-1])
+	if pad == 0 || pad > block.BlockSize() || !bytes.Equal(plain[len(plain)-pad:], bytes.Repeat([]byte{byte(pad)}, pad)) {
+		return nil, errors.New("password is incorrect")
+	}
+	plain = plain[:len(plain)-pad]
+	if _, err := x509.ParsePKCS8PrivateKey(plain); err != nil {
+		return nil, errors.New("password is incorrect")
+	}
+	return plain, nil
+}
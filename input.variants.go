@@ -0,0 +1,146 @@
+package configmapper
+
+import (
+	"fmt"
+	"mosix-go-configmapper/types"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// DiscriminatorTagName is the tag of an interface field which names the key,
+	// after the prefix of the field, which selects its variant
+	DiscriminatorTagName = "discriminator"
+	// DefaultDiscriminator is the key, after the prefix of an interface field,
+	// which selects the registered variant of the field, see RegisterVariant
+	DefaultDiscriminator = "TYPE"
+)
+
+// RegisterVariant
+// registers a struct type as a variant of the fields of the interface type iface, selected
+// when the discriminator key of the field is name (case-insensitive), e.g.
+//
+//	inp.RegisterVariant(reflect.TypeOf((*StoreConfig)(nil)).Elem(), "s3", S3Config{})
+//
+// variant is a value of the struct type, or a pointer to it, either of which must implement
+// iface, and the field is set with what implements it. The discriminator key is the prefix
+// of the field, resolved as the one of a nested struct (its prefix tag, or its name followed
+// by '_'), followed by the discriminator tag, TYPE by default, e.g. STORE_TYPE. A field with
+// neither tag keeps the prefix of its struct. The fields of the variant are mapped with the
+// same prefix, e.g. STORE_BUCKET.
+func (f *InputController) RegisterVariant(iface reflect.Type, name string, variant any) *InputController {
+	if iface == nil || iface.Kind() != reflect.Interface {
+		panic("type of a variant must be an interface")
+	}
+	var t = reflect.TypeOf(variant)
+	if t == nil || name == "" {
+		panic("name and variant cannot be empty")
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("variant %s of %s must be a struct", t.String(), iface.String()))
+	}
+	if !t.Implements(iface) {
+		t = reflect.PointerTo(t)
+	}
+	if !t.Implements(iface) {
+		panic(fmt.Sprintf("variant %s does not implement %s", t.String(), iface.String()))
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.variants == nil {
+		f.variants = map[reflect.Type]map[string]reflect.Type{}
+	}
+	if f.variants[iface] == nil {
+		f.variants[iface] = map[string]reflect.Type{}
+	}
+	f.variants[iface][name] = t
+	return f
+}
+
+// variantPrefix returns the prefix of the fields of the variant of an interface field,
+// resolved by the tags as the prefix of a nested struct is, see nestedPrefix
+func (f *InputController) variantPrefix(field reflect.StructField, prefix string) string {
+	if p, ok := field.Tag.Lookup(PrefixTagName); ok {
+		return prefix + p
+	}
+	if name := field.Tag.Get(f.tagName); name != "" {
+		return prefix + name + "_"
+	}
+	return prefix
+}
+
+// mapVariant maps an interface field which has registered variants, by instantiating the
+// variant its discriminator key names and mapping the fields of it. A field which already
+// holds the selected variant keeps the values which are neither found nor have a default.
+// It reports whether the field was handled.
+func (f *InputController) mapVariant(field reflect.Value, structField reflect.StructField, scope mapScope) bool {
+	if field.Kind() != reflect.Interface || !structField.IsExported() {
+		return false
+	}
+	var variants = f.variants[field.Type()]
+	if len(variants) == 0 {
+		return false
+	}
+	var tag = structField.Tag
+	var prefix = f.variantPrefix(structField, scope.prefix)
+	var key = prefix + DefaultDiscriminator
+	if d, ok := tag.Lookup(DiscriminatorTagName); ok && d != "" {
+		key = prefix + d
+	}
+	if _, ok := f.getValidationTags(&tag)[types.VdRequired]; ok && !f.exists(key, &tag) {
+		f.insertError(key, fmt.Errorf("field %s is required and must exist", key), ReasonNotFound)
+		return true
+	}
+	name, skipped, err := f.resolveString(key, &tag)
+	if err != nil || skipped {
+		return true
+	}
+	var names = make([]string, 0, len(variants))
+	var t reflect.Type
+	for k, v := range variants {
+		names = append(names, k)
+		if strings.EqualFold(k, strings.TrimSpace(name)) {
+			t = v
+		}
+	}
+	if t == nil {
+		sort.Strings(names)
+		f.insertError(key, fmt.Errorf("value %q of %s is not among the registered types: %s",
+			name, key, strings.Join(names, ", ")), ReasonValidation)
+		return true
+	}
+	if scope.depth >= maxNestingDepth {
+		f.insertError(key, fmt.Errorf("nested struct %s is deeper than %d levels", scope.path+structField.Name, maxNestingDepth), ReasonValidation)
+		return true
+	}
+	var ptr reflect.Value
+	switch {
+	case t.Kind() == reflect.Pointer && !field.IsNil() && field.Elem().Type() == t:
+		ptr = field.Elem()
+	case t.Kind() == reflect.Pointer:
+		ptr = reflect.New(t.Elem())
+	default:
+		ptr = reflect.New(t)
+		if !field.IsNil() && field.Elem().Type() == t {
+			ptr.Elem().Set(field.Elem())
+		}
+	}
+	// the discriminator key is owned by the interface field
+	var child = mapScope{
+		prefix:  prefix,
+		path:    scope.path + structField.Name + ".",
+		depth:   scope.depth + 1,
+		claimed: map[string]string{key: scope.path + structField.Name},
+	}
+	f.mapStruct(ptr, child)
+	if t.Kind() == reflect.Pointer {
+		field.Set(ptr)
+	} else {
+		field.Set(ptr.Elem())
+	}
+	return true
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"strconv"
+	"strings"
 )
 
 const InputEnvName = "env"
@@ -46,6 +47,17 @@ func (e *InputOsEnv) Has(key string) bool {
 	return ok
 }
 
+func (e *InputOsEnv) Keys(prefix string) []string {
+	var env = os.Environ()
+	var keys = make([]string, 0, len(env))
+	for _, kv := range env {
+		if k, _, ok := strings.Cut(kv, "="); ok && k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return filterKeys(keys, prefix)
+}
+
 func (e *InputOsEnv) GetInputName() string {
 	return InputEnvName
 }
@@ -0,0 +1,128 @@
+package inputs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// fhEmulator emulates the parts of FeatureHub Edge API used by FHInput:
+// GET /features/?apiKey=... for polling and the test-client
+// PUT /features/{apiKey}/{featureKey} for changing features
+type fhEmulator struct {
+	*httptest.Server
+
+	lock     sync.Mutex
+	apiKey   string
+	envID    string
+	features map[string]*FHValue
+
+	// down makes every request fail with 503
+	down atomic.Bool
+	// readOnly rejects updates as if apiKey is not a test key
+	readOnly atomic.Bool
+	// requests counts the requests, failed ones included
+	requests atomic.Int64
+}
+
+func newFHEmulator(t *testing.T, apiKey string) *fhEmulator {
+	var em = &fhEmulator{
+		apiKey:   apiKey,
+		envID:    "env-1",
+		features: map[string]*FHValue{},
+	}
+	em.Server = httptest.NewServer(http.HandlerFunc(em.serveHTTP))
+	t.Cleanup(em.Close)
+	return em
+}
+
+func (em *fhEmulator) set(key, typ string, value interface{}) *fhEmulator {
+	em.lock.Lock()
+	defer em.lock.Unlock()
+	em.features[key] = &FHValue{ID: key, Key: key, Type: typ, Value: value, Version: 1}
+	return em
+}
+
+func (em *fhEmulator) get(key string) FHValue {
+	em.lock.Lock()
+	defer em.lock.Unlock()
+	if v, ok := em.features[key]; ok {
+		return *v
+	}
+	return FHValue{}
+}
+
+func (em *fhEmulator) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	em.requests.Add(1)
+	if em.down.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	em.lock.Lock()
+	defer em.lock.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/features/":
+		em.serveFeatures(w, r)
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/features/"+em.apiKey+"/"):
+		em.serveUpdate(w, r)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (em *fhEmulator) serveFeatures(w http.ResponseWriter, r *http.Request) {
+	var env = FeatureHubEnvironment{ID: em.envID, Features: []FHValue{}}
+	if r.URL.Query().Get("apiKey") != em.apiKey {
+		json.NewEncoder(w).Encode([]FeatureHubEnvironment{})
+		return
+	}
+	for _, v := range em.features {
+		env.Features = append(env.Features, *v)
+	}
+	sort.Slice(env.Features, func(i, j int) bool {
+		return env.Features[i].Key < env.Features[j].Key
+	})
+	json.NewEncoder(w).Encode([]FeatureHubEnvironment{env})
+}
+
+func (em *fhEmulator) serveUpdate(w http.ResponseWriter, r *http.Request) {
+	if em.readOnly.Load() {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	key, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/features/"+em.apiKey+"/"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	feature, ok := em.features[key]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var update FHFeatureStateUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	// a locked feature can only change its value in the same request that unlocks it
+	if feature.L && update.UpdateValue && (update.Lock == nil || *update.Lock) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	if update.Lock != nil {
+		feature.L = *update.Lock
+	}
+	if update.UpdateValue {
+		feature.Value = update.Value
+	}
+	feature.Version++
+	w.WriteHeader(http.StatusOK)
+}
@@ -1,11 +1,15 @@
 package inputs
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -24,23 +28,41 @@ type FHInput struct {
 	autoRefreshInterval time.Duration
 
 	refreshCount int64
+
+	// snapshotPath is the local file every successful payload is persisted to,
+	// empty means snapshots are disabled
+	snapshotPath string
+
+	// stale is true while features are served from the snapshot because
+	// the server could not be reached
+	stale bool
+
+	// loadedAt is when the features are loaded, from the server or the snapshot
+	loadedAt time.Time
+
+	// ready is closed once the features are loaded for the first time
+	ready     chan struct{}
+	readyOnce *sync.Once
+
+	// closed is closed by Close to stop the background fetches
+	closed    chan struct{}
+	closeOnce *sync.Once
 }
 
 const InputFHName = "feature-hub"
 
+// DefaultRequestTimeout is the timeout of each request to the server
+const DefaultRequestTimeout = time.Second * 10
+
+// DefaultSnapshotRetryInterval is used for retrying the server in background
+// when FHInput has started from a snapshot and no interval is given
+const DefaultSnapshotRetryInterval = time.Second * 30
+
 func NewFHInput(addr, apiKey string) (*FHInput, error) {
-	var fh = &FHInput{
-		server:       addr,
-		apiKey:       apiKey,
-		lock:         &sync.RWMutex{},
-		refreshCount: 0,
-	}
 	if addr == "" || apiKey == "" {
 		return nil, errors.New("addr and apiKey cannot be empty")
 	}
-
-	fh.client = &http.Client{}
-	fh.client.Timeout = time.Second * 10
+	var fh = newFHInput(addr, apiKey, 0)
 	var qualifiedUrl = fh.getUrl()
 	var err = fh.fetchFeaturesWithRequest(qualifiedUrl)
 	if err != nil {
@@ -50,6 +72,204 @@ func NewFHInput(addr, apiKey string) (*FHInput, error) {
 	return fh, nil
 }
 
+// NewFHInputWithSnapshot
+// works like NewFHInput, but persists every successful payload to snapshotPath.
+// If the server cannot be reached at startup, it loads the last known good
+// payload from snapshotPath, marks itself as stale and keeps retrying the server
+// in background every retryInterval (DefaultSnapshotRetryInterval if zero) until
+// it succeeds. It only returns an error if neither the server nor the snapshot
+// is usable.
+func NewFHInputWithSnapshot(addr, apiKey, snapshotPath string, retryInterval time.Duration) (*FHInput, error) {
+	if addr == "" || apiKey == "" {
+		return nil, errors.New("addr and apiKey cannot be empty")
+	}
+	if snapshotPath == "" {
+		return nil, errors.New("snapshotPath cannot be empty")
+	}
+	var fh = newFHInput(addr, apiKey, 0)
+	fh.snapshotPath = snapshotPath
+
+	var qualifiedUrl = fh.getUrl()
+	var err = fh.fetchFeaturesWithRequest(qualifiedUrl)
+	if err == nil {
+		return fh, nil
+	}
+	if snErr := fh.loadSnapshot(); snErr != nil {
+		return nil, fmt.Errorf("server is not reachable (%s) and snapshot cannot be loaded: %s", err.Error(), snErr.Error())
+	}
+	fmt.Printf("[feature-hub] -> server is not reachable, started from snapshot %s: %s\n", snapshotPath, err.Error())
+
+	if retryInterval == 0 {
+		retryInterval = DefaultSnapshotRetryInterval
+	}
+	go func() {
+		for fh.IsStale() {
+			if !fh.sleep(retryInterval) {
+				return
+			}
+			if err := fh.fetchFeaturesWithRequest(qualifiedUrl); err != nil {
+				fmt.Printf("[feature-hub] -> still serving stale snapshot, retry failed: %s\n", err.Error())
+			}
+		}
+	}()
+	return fh, nil
+}
+
+// NewFHInputAsync
+// returns right away and fetches the features in background, retrying every
+// retryInterval (DefaultSnapshotRetryInterval if zero) until the first fetch
+// succeeds, or Close is called. Use Ready(), WaitReady() or IsReady() to find out
+// when the features are loaded; until then all getters behave as if the keys do not
+// exist. It does not start from a snapshot, use NewFHInputWithSnapshot for that.
+// timeout is the timeout of each HTTP request (DefaultRequestTimeout if zero).
+func NewFHInputAsync(addr, apiKey string, timeout, retryInterval time.Duration) (*FHInput, error) {
+	if addr == "" || apiKey == "" {
+		return nil, errors.New("addr and apiKey cannot be empty")
+	}
+	var fh = newFHInput(addr, apiKey, timeout)
+	if retryInterval == 0 {
+		retryInterval = DefaultSnapshotRetryInterval
+	}
+	var qualifiedUrl = fh.getUrl()
+	go func() {
+		for {
+			err := fh.fetchFeaturesWithRequest(qualifiedUrl)
+			if err == nil {
+				return
+			}
+			fmt.Printf("[feature-hub] -> initial fetch failed, retrying in %s: %s\n", retryInterval, err.Error())
+			if !fh.sleep(retryInterval) {
+				return
+			}
+		}
+	}()
+	return fh, nil
+}
+
+func newFHInput(addr, apiKey string, timeout time.Duration) *FHInput {
+	if timeout == 0 {
+		timeout = DefaultRequestTimeout
+	}
+	return &FHInput{
+		client:       &http.Client{Timeout: timeout},
+		server:       addr,
+		apiKey:       apiKey,
+		lock:         &sync.RWMutex{},
+		refreshCount: 0,
+		ready:        make(chan struct{}),
+		readyOnce:    &sync.Once{},
+		closed:       make(chan struct{}),
+		closeOnce:    &sync.Once{},
+	}
+}
+
+// Close stops the background fetches, i.e. the retries of NewFHInputAsync and
+// NewFHInputWithSnapshot and the auto-refreshing. The loaded features are kept.
+func (fh *FHInput) Close() error {
+	fh.closeOnce.Do(func() {
+		close(fh.closed)
+	})
+	return nil
+}
+
+// sleep waits for d, it reports false if Close is called meanwhile
+func (fh *FHInput) sleep(d time.Duration) bool {
+	var timer = time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-fh.closed:
+		return false
+	}
+}
+
+// Ready returns a channel which is closed once the features
+// are loaded for the first time, either from the server or from a snapshot
+func (fh *FHInput) Ready() <-chan struct{} {
+	return fh.ready
+}
+
+// IsReady reports whether the features are loaded
+func (fh *FHInput) IsReady() bool {
+	select {
+	case <-fh.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// WaitReady blocks until the features are loaded or ctx is done
+func (fh *FHInput) WaitReady(ctx context.Context) error {
+	select {
+	case <-fh.ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (fh *FHInput) markReady() {
+	fh.readyOnce.Do(func() {
+		close(fh.ready)
+	})
+}
+
+// IsStale reports whether the features are served from the local
+// snapshot because the server could not be reached
+func (fh *FHInput) IsStale() bool {
+	fh.lock.RLock()
+	defer fh.lock.RUnlock()
+	return fh.stale
+}
+
+// IsLive reports whether the features are fetched from the server
+func (fh *FHInput) IsLive() bool {
+	return !fh.IsStale()
+}
+
+// loadSnapshot reads the last known good payload from the snapshot file
+// and marks the features as stale
+func (fh *FHInput) loadSnapshot() error {
+	b, err := os.ReadFile(fh.snapshotPath)
+	if err != nil {
+		return err
+	}
+	features, err := fh.fromJsonToMap(b)
+	if err != nil {
+		return err
+	}
+	fh.lock.Lock()
+	fh.features = features
+	fh.stale = true
+	if info, err := os.Stat(fh.snapshotPath); err == nil {
+		fh.loadedAt = info.ModTime()
+	}
+	fh.lock.Unlock()
+	fh.markReady()
+	return nil
+}
+
+// saveSnapshot writes the payload to a temporary file next to the snapshot
+// and renames it, so a crash never leaves a half-written snapshot behind
+func (fh *FHInput) saveSnapshot(b []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(fh.snapshotPath), filepath.Base(fh.snapshotPath)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), fh.snapshotPath)
+}
+
 // AutoRefreshing
 // Warning: DO NOT USE
 // this is meant for direct specific usages and/or test purposes
@@ -64,8 +284,7 @@ func (fh *FHInput) AutoRefreshing(enable bool, duration time.Duration) *FHInput
 		}
 		var qualifiedUrl = fh.getUrl()
 		go func() {
-			for {
-				time.Sleep(fh.autoRefreshInterval)
+			for fh.sleep(fh.autoRefreshInterval) {
 				if err := fh.fetchFeaturesWithRequest(qualifiedUrl); err != nil {
 					fmt.Printf("[feature-hub] -> error in auto-refreshing, skipped this round: %s\n", err.Error())
 				} else {
@@ -87,6 +306,7 @@ func (fh *FHInput) fetchFeaturesWithRequest(fullUrl string) error {
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
 		return fmt.Errorf("non-200 status code from feature-hub server: %d", resp.StatusCode)
 	}
@@ -95,13 +315,22 @@ func (fh *FHInput) fetchFeaturesWithRequest(fullUrl string) error {
 	if err != nil {
 		return err
 	}
-	fh.lock.Lock()
-	fh.features, err = fh.fromJsonToMap(responseBody)
-	fh.lock.Unlock()
+	features, err := fh.fromJsonToMap(responseBody)
 	if err != nil {
 		return err
 	}
+	fh.lock.Lock()
+	fh.features = features
+	fh.stale = false
+	fh.loadedAt = time.Now()
+	fh.lock.Unlock()
+	fh.markReady()
 
+	if fh.snapshotPath != "" {
+		if err := fh.saveSnapshot(responseBody); err != nil {
+			fmt.Printf("[feature-hub] -> failed to write snapshot %s: %s\n", fh.snapshotPath, err.Error())
+		}
+	}
 	return nil
 }
 
@@ -194,6 +423,51 @@ func (fh *FHInput) Has(key string) bool {
 	return false
 }
 
+func (fh *FHInput) Keys(prefix string) []string {
+	fh.lock.RLock()
+	defer fh.lock.RUnlock()
+	var keys = make([]string, 0, len(fh.features))
+	for k := range fh.features {
+		keys = append(keys, k)
+	}
+	return filterKeys(keys, prefix)
+}
+
+// Lookup implements ValueInputInterfaceV2, the version of the value
+// is the version of the feature
+func (fh *FHInput) Lookup(ctx context.Context, key string) (Value, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return Value{}, false, err
+	}
+	fh.lock.RLock()
+	defer fh.lock.RUnlock()
+	val, ok := fh.features[key]
+	if !ok || val.Value == nil {
+		return Value{}, false, nil
+	}
+	var v = Value{
+		Native:    val.Value,
+		Source:    InputFHName,
+		Version:   strconv.FormatInt(val.Version, 10),
+		Timestamp: fh.loadedAt,
+	}
+	switch vv := val.Value.(type) {
+	case string:
+		v.Raw = vv
+	case float64:
+		v.Raw = strconv.FormatFloat(vv, 'f', -1, 64)
+	case bool:
+		v.Raw = strconv.FormatBool(vv)
+	default:
+		b, err := json.Marshal(vv)
+		if err != nil {
+			return Value{}, false, err
+		}
+		v.Raw = string(b)
+	}
+	return v, true, nil
+}
+
 func (fh *FHInput) Reload() error {
 	var err = fh.fetchFeaturesWithRequest(fh.getUrl())
 	if err != nil {
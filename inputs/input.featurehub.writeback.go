@@ -0,0 +1,83 @@
+package inputs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// FHFeatureStateUpdate is the body of FeatureHub Edge's test-client PUT endpoint.
+// Value is only applied if UpdateValue is true and Lock is only applied if it is not nil.
+type FHFeatureStateUpdate struct {
+	Lock        *bool       `json:"lock,omitempty"`
+	Value       interface{} `json:"value"`
+	UpdateValue bool        `json:"updateValue"`
+}
+
+// ErrFHFeatureLocked is returned when the server refuses to change a locked feature
+var ErrFHFeatureLocked = errors.New("feature is locked and its value cannot be changed")
+
+// SetFeature
+// changes the value of a feature on the server, through the test-client API of
+// FeatureHub Edge. It only works with API keys which are allowed to change features
+// (test keys). The local features are not touched, call Reload() (or InputController.Reload())
+// to pick the new value up.
+func (fh *FHInput) SetFeature(key string, value interface{}) error {
+	return fh.UpdateFeature(key, FHFeatureStateUpdate{Value: value, UpdateValue: true})
+}
+
+// LockFeature locks a feature on the server, so its value cannot be changed
+func (fh *FHInput) LockFeature(key string) error {
+	var lock = true
+	return fh.UpdateFeature(key, FHFeatureStateUpdate{Lock: &lock})
+}
+
+// UnlockFeature unlocks a feature on the server
+func (fh *FHInput) UnlockFeature(key string) error {
+	var lock = false
+	return fh.UpdateFeature(key, FHFeatureStateUpdate{Lock: &lock})
+}
+
+// UpdateFeature sends a raw state update of a feature to the test-client API of FeatureHub Edge
+func (fh *FHInput) UpdateFeature(key string, update FHFeatureStateUpdate) error {
+	if key == "" {
+		return errors.New("feature key cannot be empty")
+	}
+	body, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, fh.getFeatureUrl(key), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := fh.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("feature %s is not found on feature-hub server", key)
+	case http.StatusForbidden:
+		return errors.New("api key is not allowed to change features on feature-hub server")
+	case http.StatusPreconditionFailed:
+		return ErrFHFeatureLocked
+	}
+	return fmt.Errorf("non-2xx status code from feature-hub server: %d", resp.StatusCode)
+}
+
+// getFeatureUrl the api key is a path of environment and service key
+// itself, so only the feature key gets escaped
+func (fh *FHInput) getFeatureUrl(key string) string {
+	return fmt.Sprintf("%s/features/%s/%s", fh.server, fh.apiKey, url.PathEscape(key))
+}
@@ -0,0 +1,138 @@
+package inputs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSampleFHEmulator(t *testing.T) *fhEmulator {
+	return newFHEmulator(t, "env-1/service-key").
+		set("APP_HOST", "STRING", "example.com").
+		set("APP_PORT", "NUMBER", float64(8080)).
+		set("APP_DEBUG", "BOOLEAN", true)
+}
+
+func TestFHInput_SnapshotIsWrittenOnSuccess(t *testing.T) {
+	em := newSampleFHEmulator(t)
+
+	var snapshot = filepath.Join(t.TempDir(), "fh.snapshot.json")
+	fh, err := NewFHInputWithSnapshot(em.URL, em.apiKey, snapshot, 0)
+	assert.NoError(t, err)
+	assert.True(t, fh.IsLive())
+
+	b, err := os.ReadFile(snapshot)
+	assert.NoError(t, err)
+	features, err := fh.fromJsonToMap(b)
+	assert.NoError(t, err)
+	assert.Len(t, features, 3)
+}
+
+func TestFHInput_StartsFromSnapshotWhenServerIsDown(t *testing.T) {
+	em := newSampleFHEmulator(t)
+	var snapshot = filepath.Join(t.TempDir(), "fh.snapshot.json")
+	_, err := NewFHInputWithSnapshot(em.URL, em.apiKey, snapshot, 0)
+	assert.NoError(t, err)
+
+	em.down.Store(true)
+	_, err = NewFHInputWithSnapshot(em.URL, em.apiKey, filepath.Join(t.TempDir(), "missing.json"), 0)
+	assert.Error(t, err, "no server and no snapshot must fail")
+
+	fh, err := NewFHInputWithSnapshot(em.URL, em.apiKey, snapshot, 10*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, fh.IsStale())
+	v, err := fh.GetString("APP_HOST")
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", v)
+
+	em.down.Store(false)
+	assert.Eventually(t, fh.IsLive, time.Second, 10*time.Millisecond)
+}
+
+func TestFHInput_AsyncBecomesReady(t *testing.T) {
+	em := newSampleFHEmulator(t)
+	em.down.Store(true)
+
+	fh, err := NewFHInputAsync(em.URL, em.apiKey, time.Second, 10*time.Millisecond)
+	assert.NoError(t, err)
+	assert.False(t, fh.IsReady())
+	assert.False(t, fh.Has("APP_HOST"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, fh.WaitReady(ctx), context.DeadlineExceeded)
+
+	em.down.Store(false)
+	ctx2, cancel2 := context.WithTimeout(context.Background(), time.Second)
+	defer cancel2()
+	assert.NoError(t, fh.WaitReady(ctx2))
+	assert.True(t, fh.Has("APP_HOST"))
+}
+
+func TestFHInput_AsyncStopsOnClose(t *testing.T) {
+	em := newSampleFHEmulator(t)
+	em.down.Store(true)
+
+	fh, err := NewFHInputAsync(em.URL, em.apiKey, time.Second, 5*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return em.requests.Load() >= 2 }, time.Second, time.Millisecond)
+	assert.NoError(t, fh.Close())
+	assert.NoError(t, fh.Close(), "Close can be called more than once")
+	// a retry in flight may still land
+	time.Sleep(20 * time.Millisecond)
+	var requests = em.requests.Load()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, requests, em.requests.Load(), "no retry must be made after Close")
+	assert.False(t, fh.IsReady())
+}
+
+func TestFHInput_SetFeatureAndReload(t *testing.T) {
+	em := newSampleFHEmulator(t)
+	fh, err := NewFHInput(em.URL, em.apiKey)
+	assert.NoError(t, err)
+
+	assert.NoError(t, fh.SetFeature("APP_DEBUG", false))
+	v, _ := fh.GetBoolean("APP_DEBUG")
+	assert.True(t, v, "local value must not change before reload")
+	assert.NoError(t, fh.Reload())
+	v, _ = fh.GetBoolean("APP_DEBUG")
+	assert.False(t, v)
+
+	assert.NoError(t, fh.LockFeature("APP_HOST"))
+	assert.True(t, em.get("APP_HOST").L)
+	assert.ErrorIs(t, fh.SetFeature("APP_HOST", "changed.com"), ErrFHFeatureLocked)
+	assert.NoError(t, fh.UnlockFeature("APP_HOST"))
+	assert.NoError(t, fh.SetFeature("APP_HOST", "changed.com"))
+	assert.Equal(t, "changed.com", em.get("APP_HOST").Value)
+
+	assert.Error(t, fh.SetFeature("UNKNOWN", 1))
+	em.readOnly.Store(true)
+	assert.Error(t, fh.SetFeature("APP_PORT", 9000))
+}
+
+func TestKeyLister(t *testing.T) {
+	em := newSampleFHEmulator(t)
+	fh, err := NewFHInput(em.URL, em.apiKey)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"APP_DEBUG", "APP_HOST", "APP_PORT"}, fh.Keys(""))
+	assert.Equal(t, []string{"APP_HOST"}, fh.Keys("APP_H"))
+
+	t.Setenv("KEY_LISTER_B", "2")
+	t.Setenv("KEY_LISTER_A", "1")
+	assert.Equal(t, []string{"KEY_LISTER_A", "KEY_LISTER_B"}, NewOsEnv().Keys("KEY_LISTER_"))
+
+	mock := NewInputMock()
+	mock.KeysStr["RATE_A"] = "1"
+	mock.KeysNumber["RATE_B"] = 2
+	mock.KeysBool["OTHER"] = true
+	mock.ShouldError("RATE_C", errors.New("failed"))
+	mock.KeysStr["RATE_C"] = "3"
+	assert.Equal(t, []string{"RATE_A", "RATE_B"}, mock.Keys("RATE_"))
+
+	var _ KeyLister = fh
+}
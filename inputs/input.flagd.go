@@ -0,0 +1,305 @@
+package inputs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const InputFlagdName = "flagd"
+
+const (
+	FlagdStateEnabled  = "ENABLED"
+	FlagdStateDisabled = "DISABLED"
+)
+
+// flagd evaluation reasons, returned by InputFlagd.Evaluate
+const (
+	FlagdReasonStatic         = "STATIC"
+	FlagdReasonTargetingMatch = "TARGETING_MATCH"
+	FlagdReasonDefault        = "DEFAULT"
+	// FlagdReasonError is returned with the default variant when the targeting
+	// cannot be evaluated against the evaluation context, e.g. it has no targetingKey
+	FlagdReasonError = "ERROR"
+)
+
+// FlagdFlag is a flag of flagd's flag-definition format
+type FlagdFlag struct {
+	State          string                     `json:"state"`
+	Variants       map[string]json.RawMessage `json:"variants"`
+	DefaultVariant string                     `json:"defaultVariant"`
+	Targeting      json.RawMessage            `json:"targeting,omitempty"`
+}
+
+// FlagdDefinition is the root of flagd's flag-definition file
+type FlagdDefinition struct {
+	Flags      map[string]FlagdFlag       `json:"flags"`
+	Evaluators map[string]json.RawMessage `json:"$evaluators,omitempty"`
+}
+
+// NewFlagdFile
+// creates an input which reads flagd's flag-definition file at path.
+// The typed getters return the default variant of each enabled flag,
+// disabled flags are treated as not found. Use WithEvaluationContext
+// to get an input whose getters also evaluate the targeting rules.
+func NewFlagdFile(path string) (*InputFlagd, error) {
+	if path == "" {
+		return nil, errors.New("path cannot be empty")
+	}
+	var fd = &InputFlagd{
+		path:  path,
+		state: &flagdState{},
+	}
+	if err := fd.Reload(); err != nil {
+		return nil, err
+	}
+	return fd, nil
+}
+
+type InputFlagd struct {
+	path string
+
+	// state is shared by the inputs created by WithEvaluationContext,
+	// so they all see the flags of the last Reload
+	state *flagdState
+
+	// evalCtx is nil unless the input is created by WithEvaluationContext
+	evalCtx map[string]interface{}
+}
+
+// flagdState is the reloadable state of InputFlagd
+type flagdState struct {
+	lock  sync.RWMutex
+	flags map[string]FlagdFlag
+	// evaluators are the shared targeting rules, referenced by {"$ref": "name"}
+	evaluators map[string]interface{}
+}
+
+// WithEvaluationContext returns an input which shares the flags with fd, reloads of
+// either one included, but its getters evaluate the targeting rules of the flags against evalCtx.
+// targetingKey of evalCtx is used for fractional evaluation.
+func (fd *InputFlagd) WithEvaluationContext(evalCtx map[string]interface{}) *InputFlagd {
+	var cp = *fd
+	cp.evalCtx = evalCtx
+	if cp.evalCtx == nil {
+		cp.evalCtx = map[string]interface{}{}
+	}
+	return &cp
+}
+
+// EvaluationContext returns the evaluation context of the getters,
+// nil unless the input is created by WithEvaluationContext
+func (fd *InputFlagd) EvaluationContext() map[string]interface{} {
+	return fd.evalCtx
+}
+
+// Evaluate resolves the flag key against evalCtx and returns its value, the name
+// of the resolved variant and the reason. A nil evalCtx skips targeting.
+func (fd *InputFlagd) Evaluate(key string, evalCtx map[string]interface{}) (value interface{}, variant string, reason string, err error) {
+	fd.state.lock.RLock()
+	flag, ok := fd.state.flags[key]
+	var evaluators = fd.state.evaluators
+	fd.state.lock.RUnlock()
+	if !ok {
+		return nil, "", "", ErrFlagdNotFound
+	}
+	if flag.State == FlagdStateDisabled {
+		return nil, "", "", ErrFlagdDisabled
+	}
+
+	variant, reason = flag.DefaultVariant, FlagdReasonStatic
+	if evalCtx != nil && len(flag.Targeting) > 0 && string(flag.Targeting) != "{}" {
+		reason = FlagdReasonDefault
+		rule, err := decodeFlagdRule(flag.Targeting, evaluators)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("targeting of flag %s is invalid: %s", key, err.Error())
+		}
+		var data = make(map[string]interface{}, len(evalCtx)+1)
+		for k, v := range evalCtx {
+			data[k] = v
+		}
+		data["$flagd"] = map[string]interface{}{
+			"flagKey":   key,
+			"timestamp": float64(time.Now().Unix()),
+		}
+		res, err := evalJSONLogic(rule, data)
+		var ce contextError
+		if errors.As(err, &ce) {
+			res, reason = nil, FlagdReasonError
+		} else if err != nil {
+			return nil, "", "", fmt.Errorf("failed to evaluate targeting of flag %s: %s", key, err.Error())
+		}
+		if name, ok := res.(string); ok && name != "" {
+			variant, reason = name, FlagdReasonTargetingMatch
+		} else if b, ok := res.(bool); ok {
+			// boolean results are used as variant names, as flagd does
+			variant, reason = fmt.Sprint(b), FlagdReasonTargetingMatch
+		}
+	}
+
+	raw, ok := flag.Variants[variant]
+	if !ok {
+		return nil, "", "", fmt.Errorf("variant %s of flag %s is not defined", variant, key)
+	}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, "", "", fmt.Errorf("variant %s of flag %s cannot be decoded: %s", variant, key, err.Error())
+	}
+	return value, variant, reason, nil
+}
+
+// ErrFlagdNotFound is returned when a flag does not exist
+var ErrFlagdNotFound = errors.New("flag not found")
+
+// ErrFlagdDisabled is returned when a flag exists, but its state is DISABLED
+var ErrFlagdDisabled = errors.New("flag is disabled")
+
+func (fd *InputFlagd) value(key string) (interface{}, error) {
+	v, _, _, err := fd.Evaluate(key, fd.evalCtx)
+	return v, err
+}
+
+func (fd *InputFlagd) GetBoolean(key string) (bool, error) {
+	v, err := fd.value(key)
+	if err != nil {
+		return false, err
+	}
+	if b, ok := v.(bool); ok {
+		return b, nil
+	}
+	return false, errors.New("incompatible type for key=" + key)
+}
+
+func (fd *InputFlagd) GetNumber(key string) (float64, error) {
+	v, err := fd.value(key)
+	if err != nil {
+		return 0, err
+	}
+	if n, ok := v.(float64); ok {
+		return n, nil
+	}
+	return 0, errors.New("incompatible type for key=" + key)
+}
+
+// GetString returns string variants as they are and
+// object variants as JSON text
+func (fd *InputFlagd) GetString(key string) (string, error) {
+	v, err := fd.value(key)
+	if err != nil {
+		return "", err
+	}
+	switch vv := v.(type) {
+	case string:
+		return vv, nil
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(vv)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	return "", errors.New("incompatible type for key=" + key)
+}
+
+// Has reports whether the flag exists and is enabled
+func (fd *InputFlagd) Has(key string) bool {
+	fd.state.lock.RLock()
+	defer fd.state.lock.RUnlock()
+	flag, ok := fd.state.flags[key]
+	return ok && flag.State != FlagdStateDisabled
+}
+
+func (fd *InputFlagd) CanRefresh() bool {
+	return true
+}
+
+// Reload reads the flag-definition file again
+func (fd *InputFlagd) Reload() error {
+	b, err := os.ReadFile(fd.path)
+	if err != nil {
+		return err
+	}
+	var def FlagdDefinition
+	if err := json.Unmarshal(b, &def); err != nil {
+		return fmt.Errorf("flagd file %s is not valid: %s", fd.path, err.Error())
+	}
+	if def.Flags == nil {
+		return fmt.Errorf("flagd file %s has no flags", fd.path)
+	}
+	for k, v := range def.Flags {
+		if v.State != FlagdStateEnabled && v.State != FlagdStateDisabled {
+			return fmt.Errorf("flag %s has an unknown state %s", k, v.State)
+		}
+		if _, ok := v.Variants[v.DefaultVariant]; !ok {
+			return fmt.Errorf("default variant %s of flag %s is not defined", v.DefaultVariant, k)
+		}
+	}
+	var evaluators = make(map[string]interface{}, len(def.Evaluators))
+	for k, v := range def.Evaluators {
+		var rule interface{}
+		if err := json.Unmarshal(v, &rule); err != nil {
+			return fmt.Errorf("evaluator %s is not valid: %s", k, err.Error())
+		}
+		evaluators[k] = rule
+	}
+
+	fd.state.lock.Lock()
+	fd.state.flags = def.Flags
+	fd.state.evaluators = evaluators
+	fd.state.lock.Unlock()
+	return nil
+}
+
+func (fd *InputFlagd) GetInputName() string {
+	return InputFlagdName
+}
+
+// decodeFlagdRule decodes a targeting rule and replaces
+// every {"$ref": "name"} with the shared evaluator of that name
+func decodeFlagdRule(raw json.RawMessage, evaluators map[string]interface{}) (interface{}, error) {
+	var rule interface{}
+	if err := json.Unmarshal(raw, &rule); err != nil {
+		return nil, err
+	}
+	return resolveFlagdRefs(rule, evaluators, 0)
+}
+
+func resolveFlagdRefs(rule interface{}, evaluators map[string]interface{}, depth int) (interface{}, error) {
+	if depth > 32 {
+		return nil, errors.New("too deep $ref nesting")
+	}
+	switch v := rule.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"]; ok && len(v) == 1 {
+			name, _ := ref.(string)
+			ev, ok := evaluators[strings.TrimSpace(name)]
+			if !ok {
+				return nil, fmt.Errorf("evaluator %v is not defined", ref)
+			}
+			return resolveFlagdRefs(ev, evaluators, depth+1)
+		}
+		var out = make(map[string]interface{}, len(v))
+		for k, vv := range v {
+			r, err := resolveFlagdRefs(vv, evaluators, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+	case []interface{}:
+		var out = make([]interface{}, len(v))
+		for i, vv := range v {
+			r, err := resolveFlagdRefs(vv, evaluators, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	}
+	return rule, nil
+}
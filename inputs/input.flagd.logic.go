@@ -0,0 +1,440 @@
+package inputs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// evalJSONLogic evaluates the subset of JsonLogic used by flagd targeting rules:
+// var, if, ==, !=, ===, !==, <, <=, >, >=, !, !!, and, or, in, cat,
+// plus flagd's own starts_with, ends_with, sem_ver and fractional
+func evalJSONLogic(rule interface{}, data map[string]interface{}) (interface{}, error) {
+	switch r := rule.(type) {
+	case []interface{}:
+		var out = make([]interface{}, len(r))
+		for i, v := range r {
+			res, err := evalJSONLogic(v, data)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = res
+		}
+		return out, nil
+	case map[string]interface{}:
+		if len(r) != 1 {
+			return r, nil
+		}
+		for op, rawArgs := range r {
+			args, ok := rawArgs.([]interface{})
+			if !ok {
+				args = []interface{}{rawArgs}
+			}
+			return evalJSONLogicOp(op, args, data)
+		}
+	}
+	return rule, nil
+}
+
+// contextError marks the targeting errors caused by the evaluation context rather than
+// by the flag definition, e.g. a missing targetingKey or a version which is not semantic.
+// Flags fall back to their default variant on such errors, as flagd does.
+type contextError struct {
+	error
+}
+
+func evalJSONLogicOp(op string, args []interface{}, data map[string]interface{}) (interface{}, error) {
+	// operators which evaluate their arguments lazily
+	switch op {
+	case "if", "?:":
+		for i := 0; i+1 < len(args); i += 2 {
+			c, err := evalJSONLogic(args[i], data)
+			if err != nil {
+				return nil, err
+			}
+			if logicTruthy(c) {
+				return evalJSONLogic(args[i+1], data)
+			}
+		}
+		if len(args)%2 == 1 {
+			return evalJSONLogic(args[len(args)-1], data)
+		}
+		return nil, nil
+	case "and", "or":
+		var last interface{}
+		for _, a := range args {
+			v, err := evalJSONLogic(a, data)
+			if err != nil {
+				return nil, err
+			}
+			last = v
+			if (op == "and") != logicTruthy(v) {
+				return v, nil
+			}
+		}
+		return last, nil
+	}
+
+	var values = make([]interface{}, len(args))
+	for i, a := range args {
+		v, err := evalJSONLogic(a, data)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	var arg = func(i int) interface{} {
+		if i < len(values) {
+			return values[i]
+		}
+		return nil
+	}
+
+	switch op {
+	case "var":
+		return logicVar(arg(0), arg(1), data), nil
+	case "==":
+		return logicLooseEqual(arg(0), arg(1)), nil
+	case "!=":
+		return !logicLooseEqual(arg(0), arg(1)), nil
+	case "===":
+		return logicStrictEqual(arg(0), arg(1)), nil
+	case "!==":
+		return !logicStrictEqual(arg(0), arg(1)), nil
+	case "!":
+		return !logicTruthy(arg(0)), nil
+	case "!!":
+		return logicTruthy(arg(0)), nil
+	case "<", "<=", ">", ">=":
+		return logicCompare(op, values), nil
+	case "in":
+		return logicIn(arg(0), arg(1)), nil
+	case "cat":
+		var sb strings.Builder
+		for _, v := range values {
+			sb.WriteString(logicString(v))
+		}
+		return sb.String(), nil
+	case "starts_with":
+		return strings.HasPrefix(logicString(arg(0)), logicString(arg(1))), nil
+	case "ends_with":
+		return strings.HasSuffix(logicString(arg(0)), logicString(arg(1))), nil
+	case "sem_ver":
+		return logicSemVer(logicString(arg(0)), logicString(arg(1)), logicString(arg(2)))
+	case "fractional":
+		return logicFractional(values, data)
+	}
+	return nil, fmt.Errorf("unsupported operator %s", op)
+}
+
+func logicVar(path, def interface{}, data map[string]interface{}) interface{} {
+	var p = logicString(path)
+	if p == "" {
+		return data
+	}
+	var cur interface{} = data
+	for _, part := range strings.Split(p, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return def
+		}
+		if cur, ok = m[part]; !ok {
+			return def
+		}
+	}
+	if cur == nil {
+		return def
+	}
+	return cur
+}
+
+func logicTruthy(v interface{}) bool {
+	switch vv := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return vv
+	case float64:
+		return vv != 0
+	case string:
+		return vv != ""
+	case []interface{}:
+		return len(vv) > 0
+	}
+	return true
+}
+
+func logicString(v interface{}) string {
+	switch vv := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return vv
+	case float64:
+		return strconv.FormatFloat(vv, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+func logicNumber(v interface{}) (float64, bool) {
+	switch vv := v.(type) {
+	case float64:
+		return vv, true
+	case int:
+		return float64(vv), true
+	case bool:
+		if vv {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(vv), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func logicStrictEqual(a, b interface{}) bool {
+	switch av := a.(type) {
+	case nil:
+		return b == nil
+	case float64, string, bool:
+		return a == b
+	case int:
+		bv, ok := b.(float64)
+		return ok && float64(av) == bv
+	}
+	return false
+}
+
+func logicLooseEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	_, aStr := a.(string)
+	_, bStr := b.(string)
+	if aStr && bStr {
+		return a == b
+	}
+	an, aok := logicNumber(a)
+	bn, bok := logicNumber(b)
+	if aok && bok {
+		return an == bn
+	}
+	return logicStrictEqual(a, b)
+}
+
+// logicCompare supports the between form of < and <=, e.g. {"<": [1, {"var": "x"}, 10]}
+func logicCompare(op string, values []interface{}) bool {
+	if len(values) < 2 {
+		return false
+	}
+	var cmp = func(a, b interface{}) bool {
+		as, aStr := a.(string)
+		bs, bStr := b.(string)
+		var c int
+		if aStr && bStr {
+			c = strings.Compare(as, bs)
+		} else {
+			an, aok := logicNumber(a)
+			bn, bok := logicNumber(b)
+			if !aok || !bok {
+				return false
+			}
+			switch {
+			case an < bn:
+				c = -1
+			case an > bn:
+				c = 1
+			}
+		}
+		switch op {
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		}
+		return c >= 0
+	}
+	if len(values) == 3 && (op == "<" || op == "<=") {
+		return cmp(values[0], values[1]) && cmp(values[1], values[2])
+	}
+	return cmp(values[0], values[1])
+}
+
+func logicIn(needle, haystack interface{}) bool {
+	switch h := haystack.(type) {
+	case string:
+		return strings.Contains(h, logicString(needle))
+	case []interface{}:
+		for _, v := range h {
+			if logicStrictEqual(needle, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// logicSemVer compares two versions in major.minor.patch form, a leading v
+// and any pre-release/build suffix are ignored. ^ matches the same major
+// and ~ matches the same major and minor version.
+func logicSemVer(a, op, b string) (bool, error) {
+	av, err := parseSemVer(a)
+	if err != nil {
+		return false, contextError{err}
+	}
+	bv, err := parseSemVer(b)
+	if err != nil {
+		return false, contextError{err}
+	}
+	var c = 0
+	for i := 0; i < 3 && c == 0; i++ {
+		switch {
+		case av[i] < bv[i]:
+			c = -1
+		case av[i] > bv[i]:
+			c = 1
+		}
+	}
+	switch op {
+	case "=":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	case "^":
+		return av[0] == bv[0], nil
+	case "~":
+		return av[0] == bv[0] && av[1] == bv[1], nil
+	}
+	return false, fmt.Errorf("unsupported sem_ver operator %s", op)
+}
+
+func parseSemVer(v string) ([3]int, error) {
+	var out [3]int
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return out, fmt.Errorf("%s is not a semantic version", v)
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return out, fmt.Errorf("%s is not a semantic version", v)
+		}
+		out[i] = n
+	}
+	return out, nil
+}
+
+// logicFractional picks a variant by hashing the bucketing key with murmur3,
+// the same way flagd does. The bucketing key is the first argument if it is
+// not a distribution, otherwise flagKey concatenated with targetingKey.
+func logicFractional(values []interface{}, data map[string]interface{}) (interface{}, error) {
+	var key string
+	if len(values) > 0 {
+		if s, ok := values[0].(string); ok {
+			key = s
+			values = values[1:]
+		}
+	}
+	if key == "" {
+		tk, _ := data["targetingKey"].(string)
+		if tk == "" {
+			return nil, contextError{errors.New("fractional needs a targetingKey in evaluation context")}
+		}
+		key = logicString(logicVar("$flagd.flagKey", nil, data)) + tk
+	}
+
+	type bucket struct {
+		variant string
+		weight  float64
+	}
+	var buckets []bucket
+	var total float64
+	for _, v := range values {
+		d, ok := v.([]interface{})
+		if !ok || len(d) == 0 {
+			return nil, errors.New("fractional distribution must be [variant, weight]")
+		}
+		var b = bucket{variant: logicString(d[0]), weight: 1}
+		if len(d) > 1 {
+			w, ok := logicNumber(d[1])
+			if !ok || w < 0 {
+				return nil, fmt.Errorf("weight of variant %s is not a positive number", b.variant)
+			}
+			b.weight = w
+		}
+		total += b.weight
+		buckets = append(buckets, b)
+	}
+	if total == 0 {
+		return nil, errors.New("fractional has no weighted variants")
+	}
+
+	var hash = int32(murmur3Sum32([]byte(key), 0))
+	var point = math.Abs(float64(hash)) / math.MaxInt32 * total
+	var sum float64
+	for _, b := range buckets {
+		sum += b.weight
+		if point < sum {
+			return b.variant, nil
+		}
+	}
+	return buckets[len(buckets)-1].variant, nil
+}
+
+func murmur3Sum32(data []byte, seed uint32) uint32 {
+	const c1, c2 = 0xcc9e2d51, 0x1b873593
+	var h = seed
+	var n = len(data) / 4
+	for i := 0; i < n; i++ {
+		k := binary.LittleEndian.Uint32(data[i*4:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+	var tail = data[n*4:]
+	var k uint32
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
@@ -0,0 +1,155 @@
+package inputs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleFlagd = `{
+  "flags": {
+    "new-checkout": {
+      "state": "ENABLED",
+      "variants": {"on": true, "off": false},
+      "defaultVariant": "off",
+      "targeting": {"if": [{"$ref": "internalUsers"}, "on", null]}
+    },
+    "theme": {
+      "state": "ENABLED",
+      "variants": {"dark": "dark", "light": "light"},
+      "defaultVariant": "light",
+      "targeting": {"fractional": [["dark", 50], ["light", 50]]}
+    },
+    "max-items": {
+      "state": "ENABLED",
+      "variants": {"low": 10, "high": 100},
+      "defaultVariant": "low",
+      "targeting": {"if": [{"sem_ver": [{"var": "version"}, ">=", "2.0.0"]}, "high"]}
+    },
+    "broken": {
+      "state": "ENABLED",
+      "variants": {"on": true, "off": false},
+      "defaultVariant": "off",
+      "targeting": {"fractional": [["on", -1]]}
+    },
+    "limits": {
+      "state": "ENABLED",
+      "variants": {"default": {"rps": 10}},
+      "defaultVariant": "default"
+    },
+    "old-banner": {
+      "state": "DISABLED",
+      "variants": {"on": true},
+      "defaultVariant": "on"
+    }
+  },
+  "$evaluators": {
+    "internalUsers": {"ends_with": [{"var": "email"}, "@example.com"]}
+  }
+}`
+
+func newSampleFlagd(t *testing.T) *InputFlagd {
+	var path = filepath.Join(t.TempDir(), "flags.json")
+	assert.NoError(t, os.WriteFile(path, []byte(sampleFlagd), 0o600))
+	fd, err := NewFlagdFile(path)
+	assert.NoError(t, err)
+	return fd
+}
+
+func TestFlagd_DefaultVariants(t *testing.T) {
+	fd := newSampleFlagd(t)
+
+	b, err := fd.GetBoolean("new-checkout")
+	assert.NoError(t, err)
+	assert.False(t, b)
+	s, err := fd.GetString("theme")
+	assert.NoError(t, err)
+	assert.Equal(t, "light", s)
+	n, err := fd.GetNumber("max-items")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(10), n)
+	s, err = fd.GetString("limits")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"rps": 10}`, s)
+
+	assert.False(t, fd.Has("old-banner"))
+	_, err = fd.GetBoolean("old-banner")
+	assert.ErrorIs(t, err, ErrFlagdDisabled)
+	_, err = fd.GetBoolean("unknown")
+	assert.ErrorIs(t, err, ErrFlagdNotFound)
+	_, err = fd.GetNumber("theme")
+	assert.Error(t, err)
+}
+
+func TestFlagd_Targeting(t *testing.T) {
+	fd := newSampleFlagd(t)
+
+	internal := fd.WithEvaluationContext(map[string]interface{}{"email": "joe@example.com", "version": "2.1.0"})
+	b, err := internal.GetBoolean("new-checkout")
+	assert.NoError(t, err)
+	assert.True(t, b)
+	n, err := internal.GetNumber("max-items")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(100), n)
+
+	v, variant, reason, err := fd.Evaluate("new-checkout", map[string]interface{}{"email": "joe@other.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, false, v)
+	assert.Equal(t, "off", variant)
+	assert.Equal(t, FlagdReasonDefault, reason)
+
+	// targeting which does not fit the evaluation context falls back to the default variant
+	v, variant, reason, err = fd.Evaluate("theme", map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, "light", v)
+	assert.Equal(t, "light", variant)
+	assert.Equal(t, FlagdReasonError, reason)
+	v, _, reason, err = fd.Evaluate("max-items", map[string]interface{}{"version": "latest"})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(10), v)
+	assert.Equal(t, FlagdReasonError, reason)
+	// broken definitions are still errors
+	_, _, _, err = fd.Evaluate("broken", map[string]interface{}{"targetingKey": "u1"})
+	assert.Error(t, err)
+	var seen = map[string]bool{}
+	for _, user := range []string{"u1", "u2", "u3", "u4", "u5", "u6", "u7", "u8", "u9", "u10"} {
+		v1, _, _, err := fd.Evaluate("theme", map[string]interface{}{"targetingKey": user})
+		assert.NoError(t, err)
+		v2, _, _, _ := fd.Evaluate("theme", map[string]interface{}{"targetingKey": user})
+		assert.Equal(t, v1, v2, "bucketing must be deterministic")
+		seen[v1.(string)] = true
+	}
+	assert.Len(t, seen, 2)
+}
+
+func TestMurmur3Sum32(t *testing.T) {
+	assert.Equal(t, uint32(0), murmur3Sum32([]byte(""), 0))
+	assert.Equal(t, uint32(0x248bfa47), murmur3Sum32([]byte("hello"), 0))
+	assert.Equal(t, uint32(0x2e4ff723), murmur3Sum32([]byte("The quick brown fox jumps over the lazy dog"), 0))
+}
+
+func TestFlagd_ReloadIsSharedWithContexts(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "flags.json")
+	var write = func(variant string) {
+		assert.NoError(t, os.WriteFile(path, []byte(`{"flags": {"color": {"state": "ENABLED",
+			"variants": {"one": "one", "two": "two"}, "defaultVariant": "`+variant+`"}}}`), 0o600))
+	}
+	write("one")
+	fd, err := NewFlagdFile(path)
+	assert.NoError(t, err)
+	internal := fd.WithEvaluationContext(map[string]interface{}{"targetingKey": "u1"})
+
+	write("two")
+	assert.NoError(t, fd.Reload())
+	s, err := internal.GetString("color")
+	assert.NoError(t, err)
+	assert.Equal(t, "two", s, "a context copy must see the reloads of its parent")
+
+	write("one")
+	assert.NoError(t, internal.Reload())
+	s, err = fd.GetString("color")
+	assert.NoError(t, err)
+	assert.Equal(t, "one", s, "a parent must see the reloads of its context copies")
+}
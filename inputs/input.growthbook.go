@@ -0,0 +1,488 @@
+package inputs
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const InputGrowthBookName = "growthbook"
+
+// sources of a GrowthBook feature value, returned by InputGrowthBook.Evaluate
+const (
+	GrowthBookSourceDefault    = "defaultValue"
+	GrowthBookSourceForce      = "force"
+	GrowthBookSourceExperiment = "experiment"
+)
+
+// GrowthBookRule is a force rule or an experiment rule of a feature
+type GrowthBookRule struct {
+	Condition     map[string]interface{} `json:"condition,omitempty"`
+	Force         json.RawMessage        `json:"force,omitempty"`
+	Coverage      *float64               `json:"coverage,omitempty"`
+	HashAttribute string                 `json:"hashAttribute,omitempty"`
+	HashVersion   int                    `json:"hashVersion,omitempty"`
+	Seed          string                 `json:"seed,omitempty"`
+	Key           string                 `json:"key,omitempty"`
+	Variations    []json.RawMessage      `json:"variations,omitempty"`
+	Weights       []float64              `json:"weights,omitempty"`
+	Namespace     []interface{}          `json:"namespace,omitempty"`
+}
+
+type GrowthBookFeature struct {
+	DefaultValue json.RawMessage  `json:"defaultValue"`
+	Rules        []GrowthBookRule `json:"rules,omitempty"`
+}
+
+type growthBookPayload struct {
+	Features          map[string]GrowthBookFeature `json:"features"`
+	EncryptedFeatures string                       `json:"encryptedFeatures,omitempty"`
+}
+
+// NewGrowthBookFile creates an input which reads a GrowthBook SDK features payload from a file.
+// decryptionKey is only needed if the payload has encryptedFeatures.
+func NewGrowthBookFile(path, decryptionKey string) (*InputGrowthBook, error) {
+	if path == "" {
+		return nil, errors.New("path cannot be empty")
+	}
+	return newGrowthBook(decryptionKey, func() ([]byte, error) {
+		return os.ReadFile(path)
+	})
+}
+
+// NewGrowthBookURL creates an input which reads a GrowthBook SDK features payload from an
+// HTTP endpoint, e.g. https://cdn.growthbook.io/api/features/<clientKey>.
+// decryptionKey is only needed if the payload has encryptedFeatures.
+func NewGrowthBookURL(url, decryptionKey string) (*InputGrowthBook, error) {
+	if url == "" {
+		return nil, errors.New("url cannot be empty")
+	}
+	var client = &http.Client{Timeout: DefaultRequestTimeout}
+	return newGrowthBook(decryptionKey, func() ([]byte, error) {
+		resp, err := client.Get(url)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("non-200 status code from growthbook: %d", resp.StatusCode)
+		}
+		return io.ReadAll(resp.Body)
+	})
+}
+
+func newGrowthBook(decryptionKey string, load func() ([]byte, error)) (*InputGrowthBook, error) {
+	var gb = &InputGrowthBook{
+		load:          load,
+		decryptionKey: decryptionKey,
+		state:         &growthBookState{},
+	}
+	if err := gb.Reload(); err != nil {
+		return nil, err
+	}
+	return gb, nil
+}
+
+type InputGrowthBook struct {
+	load          func() ([]byte, error)
+	decryptionKey string
+
+	// state is shared by the inputs created by WithAttributes,
+	// so they all see the features of the last Reload
+	state *growthBookState
+
+	// attributes of the user, rules with conditions or hash
+	// attributes never match while it is empty
+	attributes map[string]interface{}
+}
+
+// growthBookState is the reloadable state of InputGrowthBook
+type growthBookState struct {
+	lock     sync.RWMutex
+	features map[string]GrowthBookFeature
+}
+
+// WithAttributes returns an input which shares the features with gb, reloads of
+// either one included, but evaluates force rules and experiments against the user attributes
+func (gb *InputGrowthBook) WithAttributes(attributes map[string]interface{}) *InputGrowthBook {
+	var cp = *gb
+	cp.attributes = attributes
+	return &cp
+}
+
+// Attributes returns the user attributes the getters evaluate the features
+// against, nil unless the input is created by WithAttributes
+func (gb *InputGrowthBook) Attributes() map[string]interface{} {
+	return gb.attributes
+}
+
+// Evaluate resolves the feature key for the given user attributes and returns
+// its value and where it comes from (default value, a force rule or an experiment)
+func (gb *InputGrowthBook) Evaluate(key string, attributes map[string]interface{}) (interface{}, string, error) {
+	gb.state.lock.RLock()
+	feature, ok := gb.state.features[key]
+	gb.state.lock.RUnlock()
+	if !ok {
+		return nil, "", errors.New("not found")
+	}
+
+	var raw, source = feature.DefaultValue, GrowthBookSourceDefault
+	for _, rule := range feature.Rules {
+		if v, ok := evalGrowthBookRule(key, rule, attributes); ok {
+			raw = v
+			if rule.Force != nil {
+				source = GrowthBookSourceForce
+			} else {
+				source = GrowthBookSourceExperiment
+			}
+			break
+		}
+	}
+	if len(raw) == 0 {
+		return nil, source, nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, "", fmt.Errorf("value of feature %s cannot be decoded: %s", key, err.Error())
+	}
+	return value, source, nil
+}
+
+func (gb *InputGrowthBook) GetBoolean(key string) (bool, error) {
+	v, _, err := gb.Evaluate(key, gb.attributes)
+	if err != nil {
+		return false, err
+	}
+	if b, ok := v.(bool); ok {
+		return b, nil
+	}
+	return false, errors.New("incompatible type for key=" + key)
+}
+
+func (gb *InputGrowthBook) GetNumber(key string) (float64, error) {
+	v, _, err := gb.Evaluate(key, gb.attributes)
+	if err != nil {
+		return 0, err
+	}
+	if n, ok := v.(float64); ok {
+		return n, nil
+	}
+	return 0, errors.New("incompatible type for key=" + key)
+}
+
+// GetString returns string values as they are and
+// object values as JSON text
+func (gb *InputGrowthBook) GetString(key string) (string, error) {
+	v, _, err := gb.Evaluate(key, gb.attributes)
+	if err != nil {
+		return "", err
+	}
+	switch vv := v.(type) {
+	case string:
+		return vv, nil
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(vv)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	return "", errors.New("incompatible type for key=" + key)
+}
+
+func (gb *InputGrowthBook) Has(key string) bool {
+	gb.state.lock.RLock()
+	defer gb.state.lock.RUnlock()
+	_, ok := gb.state.features[key]
+	return ok
+}
+
+func (gb *InputGrowthBook) CanRefresh() bool {
+	return true
+}
+
+// Reload loads the payload again and decrypts it if it is encrypted
+func (gb *InputGrowthBook) Reload() error {
+	b, err := gb.load()
+	if err != nil {
+		return err
+	}
+	var payload growthBookPayload
+	if err := json.Unmarshal(b, &payload); err != nil {
+		return fmt.Errorf("growthbook payload is not valid: %s", err.Error())
+	}
+	if payload.EncryptedFeatures != "" {
+		if gb.decryptionKey == "" {
+			return errors.New("growthbook payload is encrypted but no decryption key is given")
+		}
+		plain, err := decryptGrowthBook(payload.EncryptedFeatures, gb.decryptionKey)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt growthbook payload: %s", err.Error())
+		}
+		if err := json.Unmarshal(plain, &payload.Features); err != nil {
+			return fmt.Errorf("decrypted growthbook features are not valid: %s", err.Error())
+		}
+	}
+	if payload.Features == nil {
+		return errors.New("no features found in growthbook payload")
+	}
+	gb.state.lock.Lock()
+	gb.state.features = payload.Features
+	gb.state.lock.Unlock()
+	return nil
+}
+
+func (gb *InputGrowthBook) GetInputName() string {
+	return InputGrowthBookName
+}
+
+// decryptGrowthBook decrypts "base64(iv).base64(cipherText)" with AES-CBC
+// and PKCS7 padding, key is the base64 encoded decryption key of the SDK connection
+func decryptGrowthBook(encrypted, key string) ([]byte, error) {
+	parts := strings.SplitN(encrypted, ".", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("encrypted payload must be in iv.cipherText form")
+	}
+	k, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("decryption key is not base64: %s", err.Error())
+	}
+	iv, err := base64.StdEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() || len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, errors.New("encrypted payload has an invalid size")
+	}
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(data, data)
+	var pad = int(data[len(data)-1])
+	if pad == 0 || pad > block.BlockSize() || pad > len(data) {
+		return nil, errors.New("invalid padding, the decryption key is probably wrong")
+	}
+	return data[:len(data)-pad], nil
+}
+
+// evalGrowthBookRule returns the value of a rule if it applies to the attributes
+func evalGrowthBookRule(featureKey string, rule GrowthBookRule, attributes map[string]interface{}) (json.RawMessage, bool) {
+	if rule.Condition != nil && !evalGrowthBookCondition(rule.Condition, attributes) {
+		return nil, false
+	}
+	var hashAttr = rule.HashAttribute
+	if hashAttr == "" {
+		hashAttr = "id"
+	}
+	var hashValue = growthBookString(attributes[hashAttr])
+
+	if rule.Force != nil {
+		if rule.Coverage != nil {
+			if hashValue == "" {
+				return nil, false
+			}
+			var seed = rule.Seed
+			if seed == "" {
+				seed = featureKey
+			}
+			if growthBookHash(seed, hashValue, rule.HashVersion) > *rule.Coverage {
+				return nil, false
+			}
+		}
+		return rule.Force, true
+	}
+
+	if len(rule.Variations) == 0 || hashValue == "" {
+		return nil, false
+	}
+	var key = rule.Key
+	if key == "" {
+		key = featureKey
+	}
+	if len(rule.Namespace) == 3 && !inGrowthBookNamespace(hashValue, rule.Namespace) {
+		return nil, false
+	}
+	var seed = rule.Seed
+	if seed == "" {
+		seed = key
+	}
+	var coverage = 1.0
+	if rule.Coverage != nil {
+		coverage = *rule.Coverage
+	}
+	var n = growthBookHash(seed, hashValue, rule.HashVersion)
+	var weights = rule.Weights
+	if len(weights) != len(rule.Variations) {
+		weights = make([]float64, len(rule.Variations))
+		for i := range weights {
+			weights[i] = 1 / float64(len(rule.Variations))
+		}
+	}
+	var start float64
+	for i, w := range weights {
+		end := start + coverage*w
+		if n >= start && n < end {
+			return rule.Variations[i], true
+		}
+		start += w
+	}
+	return nil, false
+}
+
+// growthBookHash maps seed and value to [0, 1) with FNV-1a, using
+// GrowthBook's hashing algorithm of the given version (1 by default)
+func growthBookHash(seed, value string, version int) float64 {
+	var fnv32a = func(s string) uint32 {
+		h := fnv.New32a()
+		h.Write([]byte(s))
+		return h.Sum32()
+	}
+	if version == 2 {
+		return float64(fnv32a(strconv.FormatUint(uint64(fnv32a(seed+value)), 10))%10000) / 10000
+	}
+	return float64(fnv32a(value+seed)%1000) / 1000
+}
+
+func inGrowthBookNamespace(hashValue string, namespace []interface{}) bool {
+	id, _ := namespace[0].(string)
+	start, ok1 := namespace[1].(float64)
+	end, ok2 := namespace[2].(float64)
+	if !ok1 || !ok2 {
+		return false
+	}
+	var n = growthBookHash("__"+id, hashValue, 1)
+	return n >= start && n < end
+}
+
+func growthBookString(v interface{}) string {
+	switch vv := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return vv
+	case float64:
+		return strconv.FormatFloat(vv, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// evalGrowthBookCondition evaluates the MongoDB-like conditions GrowthBook uses:
+// $and, $or, $nor, $not on the top level and $eq, $ne, $in, $nin, $gt, $gte,
+// $lt, $lte, $exists and $regex on attributes (dot notation is supported)
+func evalGrowthBookCondition(cond map[string]interface{}, attributes map[string]interface{}) bool {
+	for k, v := range cond {
+		switch k {
+		case "$and":
+			for _, c := range asConditions(v) {
+				if !evalGrowthBookCondition(c, attributes) {
+					return false
+				}
+			}
+		case "$or":
+			var list = asConditions(v)
+			var matched = len(list) == 0
+			for _, c := range list {
+				if evalGrowthBookCondition(c, attributes) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return false
+			}
+		case "$nor":
+			for _, c := range asConditions(v) {
+				if evalGrowthBookCondition(c, attributes) {
+					return false
+				}
+			}
+		case "$not":
+			if c, ok := v.(map[string]interface{}); ok && evalGrowthBookCondition(c, attributes) {
+				return false
+			}
+		default:
+			if !evalGrowthBookValue(v, logicVar(k, nil, attributes)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func asConditions(v interface{}) []map[string]interface{} {
+	var list []map[string]interface{}
+	if arr, ok := v.([]interface{}); ok {
+		for _, c := range arr {
+			if m, ok := c.(map[string]interface{}); ok {
+				list = append(list, m)
+			}
+		}
+	}
+	return list
+}
+
+func evalGrowthBookValue(cond interface{}, actual interface{}) bool {
+	ops, ok := cond.(map[string]interface{})
+	if !ok || len(ops) == 0 || !isOperatorObject(ops) {
+		return logicStrictEqual(cond, actual)
+	}
+	for op, expected := range ops {
+		var matched bool
+		switch op {
+		case "$eq":
+			matched = logicStrictEqual(actual, expected)
+		case "$ne":
+			matched = !logicStrictEqual(actual, expected)
+		case "$in":
+			matched = logicIn(actual, expected)
+		case "$nin":
+			matched = !logicIn(actual, expected)
+		case "$gt", "$gte", "$lt", "$lte":
+			matched = actual != nil && logicCompare(growthBookComparisons[op], []interface{}{actual, expected})
+		case "$exists":
+			matched = (actual != nil) == logicTruthy(expected)
+		case "$regex":
+			matched = matchGrowthBookRegex(growthBookString(expected), growthBookString(actual))
+		case "$not":
+			matched = !evalGrowthBookValue(expected, actual)
+		default:
+			return false
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+var growthBookComparisons = map[string]string{"$gt": ">", "$gte": ">=", "$lt": "<", "$lte": "<="}
+
+func matchGrowthBookRegex(pattern, v string) bool {
+	re, err := regexp.Compile(pattern)
+	return err == nil && re.MatchString(v)
+}
+
+func isOperatorObject(m map[string]interface{}) bool {
+	for k := range m {
+		if !strings.HasPrefix(k, "$") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package inputs
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleGrowthBookFeatures = `{
+  "dark-mode": {"defaultValue": false, "rules": [
+    {"condition": {"country": {"$in": ["NL", "DE"]}}, "force": true},
+    {"condition": {"$or": [{"plan": "pro"}, {"age": {"$gte": 65}}]}, "force": true}
+  ]},
+  "max-items": {"defaultValue": 10, "rules": [
+    {"force": 100, "coverage": 0, "hashAttribute": "id"}
+  ]},
+  "button-color": {"defaultValue": "blue", "rules": [
+    {"key": "button-exp", "variations": ["red", "green"], "weights": [0.5, 0.5], "coverage": 1, "hashAttribute": "id"}
+  ]},
+  "limits": {"defaultValue": {"rps": 10}}
+}`
+
+func writeGrowthBookFile(t *testing.T, content string) string {
+	var path = filepath.Join(t.TempDir(), "features.json")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestGrowthBook_DefaultsAndRules(t *testing.T) {
+	gb, err := NewGrowthBookFile(writeGrowthBookFile(t, `{"features": `+sampleGrowthBookFeatures+`}`), "")
+	assert.NoError(t, err)
+
+	b, err := gb.GetBoolean("dark-mode")
+	assert.NoError(t, err)
+	assert.False(t, b)
+	n, err := gb.GetNumber("max-items")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(10), n)
+	s, err := gb.GetString("button-color")
+	assert.NoError(t, err)
+	assert.Equal(t, "blue", s, "experiments need a hash attribute")
+	s, err = gb.GetString("limits")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"rps":10}`, s)
+
+	b, _ = gb.WithAttributes(map[string]interface{}{"country": "NL"}).GetBoolean("dark-mode")
+	assert.True(t, b)
+	b, _ = gb.WithAttributes(map[string]interface{}{"age": float64(70)}).GetBoolean("dark-mode")
+	assert.True(t, b)
+	b, _ = gb.WithAttributes(map[string]interface{}{"country": "US", "age": float64(30)}).GetBoolean("dark-mode")
+	assert.False(t, b)
+
+	n, _ = gb.WithAttributes(map[string]interface{}{"id": "u1"}).GetNumber("max-items")
+	assert.Equal(t, float64(10), n, "a force rule with zero coverage never applies")
+
+	var seen = map[string]bool{}
+	for _, id := range []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"} {
+		v, source, err := gb.Evaluate("button-color", map[string]interface{}{"id": id})
+		assert.NoError(t, err)
+		assert.Equal(t, GrowthBookSourceExperiment, source)
+		seen[v.(string)] = true
+	}
+	assert.Len(t, seen, 2)
+}
+
+func TestGrowthBook_EncryptedPayloadFromURL(t *testing.T) {
+	var key = make([]byte, 16)
+	var iv = make([]byte, 16)
+	for i := range key {
+		key[i] = byte(i)
+		iv[i] = byte(100 + i)
+	}
+	var plain = []byte(sampleGrowthBookFeatures)
+	var pad = aes.BlockSize - len(plain)%aes.BlockSize
+	for i := 0; i < pad; i++ {
+		plain = append(plain, byte(pad))
+	}
+	block, _ := aes.NewCipher(key)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(plain, plain)
+	var encrypted = base64.StdEncoding.EncodeToString(iv) + "." + base64.StdEncoding.EncodeToString(plain)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": 200, "features": {}, "encryptedFeatures": "` + encrypted + `"}`))
+	}))
+	defer srv.Close()
+
+	_, err := NewGrowthBookURL(srv.URL, "")
+	assert.Error(t, err)
+	_, err = NewGrowthBookURL(srv.URL, base64.StdEncoding.EncodeToString(make([]byte, 16)))
+	assert.Error(t, err, "a wrong key must fail")
+
+	gb, err := NewGrowthBookURL(srv.URL, base64.StdEncoding.EncodeToString(key))
+	assert.NoError(t, err)
+	s, err := gb.GetString("button-color")
+	assert.NoError(t, err)
+	assert.Equal(t, "blue", s)
+}
+
+func TestGrowthBookHash(t *testing.T) {
+	assert.Equal(t, 0.22, growthBookHash("", "a", 1))
+	assert.Equal(t, 0.077, growthBookHash("", "b", 1))
+	assert.Equal(t, 0.946, growthBookHash("b", "a", 1))
+}
+
+func TestGrowthBook_ReloadIsSharedWithAttributes(t *testing.T) {
+	var path = writeGrowthBookFile(t, `{"features": {"max-items": {"defaultValue": 10}}}`)
+	gb, err := NewGrowthBookFile(path, "")
+	assert.NoError(t, err)
+	joe := gb.WithAttributes(map[string]interface{}{"id": "joe"})
+
+	assert.NoError(t, os.WriteFile(path, []byte(`{"features": {"max-items": {"defaultValue": 20}}}`), 0o600))
+	assert.NoError(t, gb.Reload())
+	n, err := joe.GetNumber("max-items")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(20), n, "an attributes copy must see the reloads of its parent")
+}
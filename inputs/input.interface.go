@@ -1,5 +1,10 @@
 package inputs
 
+import (
+	"sort"
+	"strings"
+)
+
 type ValueInputInterface interface {
 	GetBoolean(key string) (bool, error)
 	GetNumber(key string) (float64, error)
@@ -18,3 +23,36 @@ type ValueInputInterface interface {
 	// GetInputName it simply returns current input source name
 	GetInputName() string
 }
+
+// ReadyNotifier is implemented by inputs which load their values
+// asynchronously, e.g. FHInput created by NewFHInputAsync.
+// Ready is closed once the values are loaded for the first time.
+type ReadyNotifier interface {
+	Ready() <-chan struct{}
+	IsReady() bool
+}
+
+// ChangeNotifier is implemented by inputs whose values are pushed to them,
+// e.g. InputWebhook. The listeners are called after the values are changed.
+type ChangeNotifier interface {
+	OnChange(fn func())
+}
+
+// KeyLister is implemented by inputs which can enumerate the keys they hold
+type KeyLister interface {
+	// Keys returns the keys which start with prefix in sorted order,
+	// an empty prefix returns all the keys
+	Keys(prefix string) []string
+}
+
+// filterKeys returns the keys which start with prefix, sorted
+func filterKeys(keys []string, prefix string) []string {
+	var list = make([]string, 0, len(keys))
+	for _, k := range keys {
+		if strings.HasPrefix(k, prefix) {
+			list = append(list, k)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
@@ -30,9 +30,36 @@ func (f *InputMock) Has(key string) bool {
 	if _, ok := f.KeysStr[key]; ok {
 		return true
 	}
+	if _, ok := f.KeysNumber[key]; ok {
+		return true
+	}
+	if _, ok := f.KeysBool[key]; ok {
+		return true
+	}
 	return false
 }
 
+// Keys returns the keys of all the value maps, except the ones which should error
+func (f *InputMock) Keys(prefix string) []string {
+	var set = map[string]bool{}
+	for k := range f.KeysStr {
+		set[k] = true
+	}
+	for k := range f.KeysNumber {
+		set[k] = true
+	}
+	for k := range f.KeysBool {
+		set[k] = true
+	}
+	var keys = make([]string, 0, len(set))
+	for k := range set {
+		if _, ok := f.ShouldErr[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	return filterKeys(keys, prefix)
+}
+
 func (f *InputMock) GetBoolean(key string) (bool, error) {
 	if v, ok := f.ShouldErr[key]; ok {
 		return false, v
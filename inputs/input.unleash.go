@@ -0,0 +1,365 @@
+package inputs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const InputUnleashName = "unleash"
+
+// variantSeed is the murmur3 seed Unleash SDKs use for variant bucketing
+const variantSeed = 86028157
+
+// UnleashContext is the context toggles and variants are evaluated against
+type UnleashContext struct {
+	UserID        string
+	SessionID     string
+	RemoteAddress string
+	Properties    map[string]string
+}
+
+type UnleashStrategy struct {
+	Name        string            `json:"name"`
+	Parameters  map[string]string `json:"parameters"`
+	Constraints []json.RawMessage `json:"constraints,omitempty"`
+}
+
+type UnleashPayload struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type UnleashOverride struct {
+	ContextName string   `json:"contextName"`
+	Values      []string `json:"values"`
+}
+
+type UnleashVariant struct {
+	Name       string            `json:"name"`
+	Weight     int               `json:"weight"`
+	Stickiness string            `json:"stickiness,omitempty"`
+	Payload    *UnleashPayload   `json:"payload,omitempty"`
+	Overrides  []UnleashOverride `json:"overrides,omitempty"`
+}
+
+type UnleashFeature struct {
+	Name       string            `json:"name"`
+	Enabled    bool              `json:"enabled"`
+	Strategies []UnleashStrategy `json:"strategies"`
+	Variants   []UnleashVariant  `json:"variants"`
+}
+
+type unleashFeaturesResponse struct {
+	Version  int              `json:"version"`
+	Features []UnleashFeature `json:"features"`
+}
+
+// NewUnleash
+// creates an input which reads the toggles from the client API of an Unleash server
+// (addr/api/client/features) and caches them. GetBoolean reports whether a toggle is
+// enabled, GetString and GetNumber return the payload of the selected variant.
+// Toggles are evaluated against an empty context, use WithContext for a user context.
+func NewUnleash(addr, apiToken, appName string) (*InputUnleash, error) {
+	if addr == "" || apiToken == "" {
+		return nil, errors.New("addr and apiToken cannot be empty")
+	}
+	if appName == "" {
+		appName = "mosix-go-configmapper"
+	}
+	var un = &InputUnleash{
+		client:   &http.Client{Timeout: DefaultRequestTimeout},
+		server:   strings.TrimSuffix(addr, "/"),
+		apiToken: apiToken,
+		appName:  appName,
+		state:    &unleashState{},
+	}
+	if err := un.Reload(); err != nil {
+		return nil, err
+	}
+	return un, nil
+}
+
+type InputUnleash struct {
+	client   *http.Client
+	server   string
+	apiToken string
+	appName  string
+
+	// state is shared by the inputs created by WithContext,
+	// so they all see the toggles of the last Reload
+	state *unleashState
+
+	ctx UnleashContext
+}
+
+// unleashState is the reloadable state of InputUnleash
+type unleashState struct {
+	lock     sync.RWMutex
+	features map[string]UnleashFeature
+}
+
+// WithContext returns an input which shares the cached toggles with un, reloads
+// of either one included, but evaluates them against ctx
+func (un *InputUnleash) WithContext(ctx UnleashContext) *InputUnleash {
+	var cp = *un
+	cp.ctx = ctx
+	return &cp
+}
+
+// Context returns the context the getters evaluate the toggles against
+func (un *InputUnleash) Context() UnleashContext {
+	return un.ctx
+}
+
+// IsEnabled evaluates the activation strategies of the toggle against ctx
+func (un *InputUnleash) IsEnabled(name string, ctx UnleashContext) bool {
+	f, ok := un.feature(name)
+	return ok && un.isEnabled(f, ctx)
+}
+
+// GetVariant returns the variant of an enabled toggle selected for ctx
+func (un *InputUnleash) GetVariant(name string, ctx UnleashContext) (UnleashVariant, bool) {
+	f, ok := un.feature(name)
+	if !ok || !un.isEnabled(f, ctx) {
+		return UnleashVariant{}, false
+	}
+	return selectUnleashVariant(f, ctx)
+}
+
+func (un *InputUnleash) feature(name string) (UnleashFeature, bool) {
+	un.state.lock.RLock()
+	defer un.state.lock.RUnlock()
+	f, ok := un.state.features[name]
+	return f, ok
+}
+
+func (un *InputUnleash) isEnabled(f UnleashFeature, ctx UnleashContext) bool {
+	if !f.Enabled {
+		return false
+	}
+	if len(f.Strategies) == 0 {
+		return true
+	}
+	for _, s := range f.Strategies {
+		if evalUnleashStrategy(f.Name, s, ctx) {
+			return true
+		}
+	}
+	return false
+}
+
+func (un *InputUnleash) GetBoolean(key string) (bool, error) {
+	f, ok := un.feature(key)
+	if !ok {
+		return false, errors.New("not found")
+	}
+	return un.isEnabled(f, un.ctx), nil
+}
+
+// GetString returns the payload of the selected variant, JSON payloads are returned as they are
+func (un *InputUnleash) GetString(key string) (string, error) {
+	v, ok := un.GetVariant(key, un.ctx)
+	if !ok || v.Payload == nil {
+		return "", errors.New("no variant payload for key=" + key)
+	}
+	return v.Payload.Value, nil
+}
+
+func (un *InputUnleash) GetNumber(key string) (float64, error) {
+	v, ok := un.GetVariant(key, un.ctx)
+	if !ok || v.Payload == nil {
+		return 0, errors.New("no variant payload for key=" + key)
+	}
+	return strconv.ParseFloat(v.Payload.Value, 64)
+}
+
+func (un *InputUnleash) Has(key string) bool {
+	_, ok := un.feature(key)
+	return ok
+}
+
+func (un *InputUnleash) CanRefresh() bool {
+	return true
+}
+
+// Reload fetches the toggles from the server again
+func (un *InputUnleash) Reload() error {
+	req, err := http.NewRequest(http.MethodGet, un.server+"/api/client/features", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", un.apiToken)
+	req.Header.Set("UNLEASH-APPNAME", un.appName)
+	req.Header.Set("Accept", "application/json")
+	resp, err := un.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("non-200 status code from unleash server: %d", resp.StatusCode)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var res unleashFeaturesResponse
+	if err := json.Unmarshal(b, &res); err != nil {
+		return err
+	}
+	var features = make(map[string]UnleashFeature, len(res.Features))
+	for _, v := range res.Features {
+		features[v.Name] = v
+	}
+	un.state.lock.Lock()
+	un.state.features = features
+	un.state.lock.Unlock()
+	return nil
+}
+
+func (un *InputUnleash) GetInputName() string {
+	return InputUnleashName
+}
+
+// evalUnleashStrategy evaluates the standard activation strategies. Strategies with
+// constraints are not supported and never match, so a toggle is never enabled by mistake.
+func evalUnleashStrategy(featureName string, s UnleashStrategy, ctx UnleashContext) bool {
+	if len(s.Constraints) > 0 {
+		return false
+	}
+	var groupID = s.Parameters["groupId"]
+	if groupID == "" {
+		groupID = featureName
+	}
+	switch s.Name {
+	case "default":
+		return true
+	case "userWithId":
+		return ctx.UserID != "" && inCommaList(s.Parameters["userIds"], ctx.UserID)
+	case "remoteAddress":
+		return ctx.RemoteAddress != "" && matchesIPList(s.Parameters["IPs"], ctx.RemoteAddress)
+	case "gradualRolloutUserId":
+		return ctx.UserID != "" && unleashNormalizedHash(ctx.UserID, groupID, 100, 0) <= percentage(s.Parameters["percentage"])
+	case "gradualRolloutSessionId":
+		return ctx.SessionID != "" && unleashNormalizedHash(ctx.SessionID, groupID, 100, 0) <= percentage(s.Parameters["percentage"])
+	case "gradualRolloutRandom":
+		return rand.Intn(100)+1 <= percentage(s.Parameters["percentage"])
+	case "flexibleRollout":
+		id, ok := unleashStickiness(s.Parameters["stickiness"], ctx)
+		if !ok {
+			return false
+		}
+		return unleashNormalizedHash(id, groupID, 100, 0) <= percentage(s.Parameters["rollout"])
+	}
+	return false
+}
+
+func selectUnleashVariant(f UnleashFeature, ctx UnleashContext) (UnleashVariant, bool) {
+	var total int
+	for _, v := range f.Variants {
+		for _, o := range v.Overrides {
+			if value, ok := unleashContextValue(o.ContextName, ctx); ok {
+				for _, ov := range o.Values {
+					if ov == value {
+						return v, true
+					}
+				}
+			}
+		}
+		total += v.Weight
+	}
+	if total <= 0 {
+		return UnleashVariant{}, false
+	}
+	id, ok := unleashStickiness(f.Variants[0].Stickiness, ctx)
+	if !ok {
+		id = strconv.Itoa(rand.Int())
+	}
+	var target = unleashNormalizedHash(id, f.Name, total, variantSeed)
+	var counter int
+	for _, v := range f.Variants {
+		counter += v.Weight
+		if counter >= target {
+			return v, true
+		}
+	}
+	return UnleashVariant{}, false
+}
+
+// unleashStickiness returns the context value a rollout is sticky to, "default"
+// (or empty) means userId, then sessionId, then a random value
+func unleashStickiness(stickiness string, ctx UnleashContext) (string, bool) {
+	if stickiness == "" || stickiness == "default" {
+		if ctx.UserID != "" {
+			return ctx.UserID, true
+		}
+		if ctx.SessionID != "" {
+			return ctx.SessionID, true
+		}
+		return strconv.Itoa(rand.Int()), true
+	}
+	if stickiness == "random" {
+		return strconv.Itoa(rand.Int()), true
+	}
+	return unleashContextValue(stickiness, ctx)
+}
+
+func unleashContextValue(name string, ctx UnleashContext) (string, bool) {
+	var v string
+	switch name {
+	case "userId":
+		v = ctx.UserID
+	case "sessionId":
+		v = ctx.SessionID
+	case "remoteAddress":
+		v = ctx.RemoteAddress
+	default:
+		v = ctx.Properties[name]
+	}
+	return v, v != ""
+}
+
+// unleashNormalizedHash maps groupId:id to 1..normalizer, the same way Unleash SDKs do
+func unleashNormalizedHash(id, groupID string, normalizer int, seed uint32) int {
+	return int(murmur3Sum32([]byte(groupID+":"+id), seed)%uint32(normalizer)) + 1
+}
+
+func percentage(v string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func inCommaList(list, v string) bool {
+	for _, item := range strings.Split(list, ",") {
+		if strings.TrimSpace(item) == v {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesIPList checks an address against a comma separated list of IPs and CIDRs
+func matchesIPList(list, addr string) bool {
+	var ip = net.ParseIP(addr)
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == addr {
+			return true
+		}
+		if _, cidr, err := net.ParseCIDR(item); err == nil && ip != nil && cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package inputs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorded from /api/client/features of an Unleash server
+const sampleUnleashPayload = `{"version":2,"features":[
+{"name":"new-checkout","enabled":true,"strategies":[{"name":"default","parameters":{}}],"variants":[]},
+{"name":"beta-users","enabled":true,"strategies":[{"name":"userWithId","parameters":{"userIds":"joe, jane"}}],"variants":[]},
+{"name":"office-only","enabled":true,"strategies":[{"name":"remoteAddress","parameters":{"IPs":"192.168.1.10,10.0.0.0/8"}}],"variants":[]},
+{"name":"half-rollout","enabled":true,"strategies":[{"name":"flexibleRollout","parameters":{"rollout":"50","stickiness":"default","groupId":"half-rollout"}}],"variants":[]},
+{"name":"disabled","enabled":false,"strategies":[{"name":"default","parameters":{}}],"variants":[]},
+{"name":"constrained","enabled":true,"strategies":[{"name":"default","parameters":{},"constraints":[{"contextName":"environment","operator":"IN","values":["prod"]}]}],"variants":[]},
+{"name":"theme","enabled":true,"strategies":[{"name":"default","parameters":{}}],"variants":[
+	{"name":"dark","weight":500,"stickiness":"default","payload":{"type":"string","value":"dark"},"overrides":[{"contextName":"userId","values":["joe"]}]},
+	{"name":"light","weight":500,"stickiness":"default","payload":{"type":"string","value":"light"}}]},
+{"name":"limits","enabled":true,"strategies":[],"variants":[
+	{"name":"default","weight":1000,"payload":{"type":"json","value":"{\"rps\":10}"}}]},
+{"name":"max-items","enabled":true,"strategies":[],"variants":[
+	{"name":"default","weight":1000,"payload":{"type":"number","value":"25"}}]}
+]}`
+
+func newSampleUnleash(t *testing.T) *InputUnleash {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/client/features" || r.Header.Get("Authorization") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(sampleUnleashPayload))
+	}))
+	t.Cleanup(srv.Close)
+	un, err := NewUnleash(srv.URL, "token", "test")
+	assert.NoError(t, err)
+	return un
+}
+
+func TestUnleash_Strategies(t *testing.T) {
+	un := newSampleUnleash(t)
+
+	v, err := un.GetBoolean("new-checkout")
+	assert.NoError(t, err)
+	assert.True(t, v)
+	v, _ = un.GetBoolean("disabled")
+	assert.False(t, v)
+	v, _ = un.GetBoolean("constrained")
+	assert.False(t, v, "strategies with constraints are not supported and must not match")
+	_, err = un.GetBoolean("unknown")
+	assert.Error(t, err)
+
+	assert.False(t, un.IsEnabled("beta-users", UnleashContext{}))
+	assert.True(t, un.IsEnabled("beta-users", UnleashContext{UserID: "jane"}))
+	assert.True(t, un.IsEnabled("office-only", UnleashContext{RemoteAddress: "10.1.2.3"}))
+	assert.False(t, un.IsEnabled("office-only", UnleashContext{RemoteAddress: "172.16.0.1"}))
+
+	var enabled int
+	for _, id := range []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12"} {
+		first := un.IsEnabled("half-rollout", UnleashContext{UserID: id})
+		assert.Equal(t, first, un.IsEnabled("half-rollout", UnleashContext{UserID: id}), "rollout must be sticky")
+		if first {
+			enabled++
+		}
+	}
+	assert.True(t, enabled > 0 && enabled < 12)
+}
+
+func TestUnleash_Variants(t *testing.T) {
+	un := newSampleUnleash(t)
+
+	s, err := un.WithContext(UnleashContext{UserID: "joe"}).GetString("theme")
+	assert.NoError(t, err)
+	assert.Equal(t, "dark", s, "override must win")
+
+	s, err = un.GetString("limits")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"rps":10}`, s)
+	n, err := un.GetNumber("max-items")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(25), n)
+	_, err = un.GetString("new-checkout")
+	assert.Error(t, err)
+}
+
+func TestUnleashNormalizedHash(t *testing.T) {
+	assert.Equal(t, 73, unleashNormalizedHash("123", "gr1", 100, 0))
+	assert.Equal(t, 25, unleashNormalizedHash("999", "groupX", 100, 0))
+}
+
+func TestUnleash_ReloadIsSharedWithContexts(t *testing.T) {
+	var payload atomic.Value
+	payload.Store(`{"version":2,"features":[{"name":"beta","enabled":false,"strategies":[]}]}`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(payload.Load().(string)))
+	}))
+	t.Cleanup(srv.Close)
+	un, err := NewUnleash(srv.URL, "token", "test")
+	assert.NoError(t, err)
+	joe := un.WithContext(UnleashContext{UserID: "joe"})
+	v, _ := joe.GetBoolean("beta")
+	assert.False(t, v)
+
+	payload.Store(`{"version":2,"features":[{"name":"beta","enabled":true,"strategies":[]}]}`)
+	assert.NoError(t, un.Reload())
+	v, err = joe.GetBoolean("beta")
+	assert.NoError(t, err)
+	assert.True(t, v, "a context copy must see the reloads of its parent")
+}
@@ -0,0 +1,184 @@
+package inputs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Value is the value of a key looked up from an input, with its metadata
+type Value struct {
+	// Raw is the value in text form
+	Raw string
+	// Native is the value as the input holds it, e.g. string, float64 or bool
+	Native interface{}
+	// Source is the name of the input the value is read from
+	Source string
+	// Version is the version of the value, empty if the input does not version its values
+	Version string
+	// Timestamp is when the input loaded the value, zero if it is not known
+	Timestamp time.Time
+}
+
+// AsString returns the value as a string, numbers and booleans are not converted
+func (v Value) AsString() (string, error) {
+	if s, ok := v.Native.(string); ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("value of type %T is not a string", v.Native)
+}
+
+// AsNumber returns numbers as they are and parses strings
+func (v Value) AsNumber() (float64, error) {
+	switch n := v.Native.(type) {
+	case float64:
+		return n, nil
+	case string:
+		return strconv.ParseFloat(n, 64)
+	}
+	return 0, fmt.Errorf("value of type %T is not a number", v.Native)
+}
+
+// AsBoolean returns booleans as they are and parses strings
+func (v Value) AsBoolean() (bool, error) {
+	switch b := v.Native.(type) {
+	case bool:
+		return b, nil
+	case string:
+		return strconv.ParseBool(b)
+	}
+	return false, fmt.Errorf("value of type %T is not a boolean", v.Native)
+}
+
+// ValueInputInterfaceV2 is a context-aware input. Lookup returns false if the key is not
+// found, and an error if the lookup itself failed, e.g. ctx is done or the source is down.
+type ValueInputInterfaceV2 interface {
+	Lookup(ctx context.Context, key string) (Value, bool, error)
+
+	// GetInputName it simply returns current input source name
+	GetInputName() string
+}
+
+// AsV2 returns in as a ValueInputInterfaceV2. Inputs which implement v1 only are adapted:
+// their getters are called in background, so a done ctx returns right away even if
+// the getter itself blocks.
+func AsV2(in ValueInputInterface) ValueInputInterfaceV2 {
+	if v2, ok := in.(ValueInputInterfaceV2); ok {
+		return v2
+	}
+	return &v1Adapter{in: in}
+}
+
+type v1Adapter struct {
+	in ValueInputInterface
+}
+
+func (a *v1Adapter) Lookup(ctx context.Context, key string) (Value, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return Value{}, false, err
+	}
+	if ctx.Done() == nil {
+		v, ok := lookupV1(a.in, key)
+		return v, ok, nil
+	}
+	type result struct {
+		v  Value
+		ok bool
+	}
+	var ch = make(chan result, 1)
+	go func() {
+		v, ok := lookupV1(a.in, key)
+		ch <- result{v, ok}
+	}()
+	select {
+	case r := <-ch:
+		return r.v, r.ok, nil
+	case <-ctx.Done():
+		return Value{}, false, ctx.Err()
+	}
+}
+
+func (a *v1Adapter) GetInputName() string {
+	return a.in.GetInputName()
+}
+
+// lookupV1 tries the getters of a v1 input, string first
+func lookupV1(in ValueInputInterface, key string) (Value, bool) {
+	if !in.Has(key) {
+		return Value{}, false
+	}
+	var v = Value{Source: in.GetInputName()}
+	if s, err := in.GetString(key); err == nil {
+		v.Raw, v.Native = s, s
+	} else if n, err := in.GetNumber(key); err == nil {
+		v.Raw, v.Native = strconv.FormatFloat(n, 'f', -1, 64), n
+	} else if b, err := in.GetBoolean(key); err == nil {
+		v.Raw, v.Native = strconv.FormatBool(b), b
+	} else {
+		return Value{}, false
+	}
+	return v, true
+}
+
+// AsV1 returns in as a ValueInputInterface, so it can be given to an InputController.
+// The getters look the keys up with context.Background(), while the controller uses
+// Lookup directly when it maps with a context (see FetchKeysAndMapThemContext).
+func AsV1(in ValueInputInterfaceV2) ValueInputInterface {
+	if v1, ok := in.(ValueInputInterface); ok {
+		return v1
+	}
+	return &v2Adapter{ValueInputInterfaceV2: in}
+}
+
+type v2Adapter struct {
+	ValueInputInterfaceV2
+}
+
+func (a *v2Adapter) lookup(key string) (Value, error) {
+	v, ok, err := a.Lookup(context.Background(), key)
+	if err != nil {
+		return v, err
+	} else if !ok {
+		return v, errors.New("key is not found")
+	}
+	return v, nil
+}
+
+func (a *v2Adapter) GetBoolean(key string) (bool, error) {
+	v, err := a.lookup(key)
+	if err != nil {
+		return false, err
+	}
+	return v.AsBoolean()
+}
+
+func (a *v2Adapter) GetNumber(key string) (float64, error) {
+	v, err := a.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	return v.AsNumber()
+}
+
+func (a *v2Adapter) GetString(key string) (string, error) {
+	v, err := a.lookup(key)
+	if err != nil {
+		return "", err
+	}
+	return v.AsString()
+}
+
+func (a *v2Adapter) Has(key string) bool {
+	_, err := a.lookup(key)
+	return err == nil
+}
+
+func (a *v2Adapter) CanRefresh() bool {
+	return false
+}
+
+func (a *v2Adapter) Reload() error {
+	return errors.New("is not implemented")
+}
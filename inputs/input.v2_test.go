@@ -0,0 +1,79 @@
+package inputs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type blockingInput struct {
+	*InputMock
+	unblock chan struct{}
+}
+
+func (b *blockingInput) GetString(key string) (string, error) {
+	<-b.unblock
+	return b.InputMock.GetString(key)
+}
+
+func TestAsV2_AdaptsV1Inputs(t *testing.T) {
+	mock := NewInputMock()
+	mock.KeysStr["HOST"] = "example.com"
+	mock.KeysNumber["PORT"] = 8080
+	mock.KeysBool["DEBUG"] = true
+
+	in := AsV2(mock)
+	v, ok, err := in.Lookup(context.Background(), "PORT")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Value{Raw: "8080", Native: float64(8080), Source: InputMockName}, v)
+	v, ok, _ = in.Lookup(context.Background(), "DEBUG")
+	assert.True(t, ok)
+	assert.Equal(t, "true", v.Raw)
+	_, ok, err = in.Lookup(context.Background(), "UNKNOWN")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// a blocking getter does not block a lookup whose context is done
+	slow := &blockingInput{InputMock: mock, unblock: make(chan struct{})}
+	defer close(slow.unblock)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, _, err = AsV2(slow).Lookup(ctx, "HOST")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestAsV1_AdaptsV2Inputs(t *testing.T) {
+	mock := NewInputMock()
+	mock.KeysStr["PORT"] = "8080"
+	in := AsV1(AsV2(mock))
+	assert.True(t, in.Has("PORT"))
+	n, err := in.GetNumber("PORT")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(8080), n)
+	_, err = in.GetBoolean("UNKNOWN")
+	assert.Error(t, err)
+	assert.Equal(t, InputMockName, in.GetInputName())
+}
+
+func TestFHInput_Lookup(t *testing.T) {
+	em := newSampleFHEmulator(t)
+	fh, err := NewFHInput(em.URL, em.apiKey)
+	assert.NoError(t, err)
+
+	var in ValueInputInterfaceV2 = fh
+	v, ok, err := in.Lookup(context.Background(), "APP_PORT")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "8080", v.Raw)
+	assert.Equal(t, InputFHName, v.Source)
+	assert.NotEmpty(t, v.Version)
+	assert.False(t, v.Timestamp.IsZero())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = in.Lookup(ctx, "APP_PORT")
+	assert.ErrorIs(t, err, context.Canceled)
+}
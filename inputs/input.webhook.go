@@ -0,0 +1,214 @@
+package inputs
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+const InputWebhookName = "webhook"
+
+// WebhookSignatureHeader carries the HMAC-SHA256 signature of the
+// request body, in sha256=<hex> form
+const WebhookSignatureHeader = "X-Signature-256"
+
+// WebhookMaxBodySize is the largest accepted update body
+const WebhookMaxBodySize = 1 << 20
+
+// NewWebhook
+// creates an input which is filled by pushed updates instead of polling. It is an
+// http.Handler which accepts POST/PUT bodies signed with secret (see WebhookSignatureHeader).
+// A body can either be a generic JSON object of key/values (a null value removes the key),
+// or a FeatureHub payload, i.e. a list of environments or an object with a features list.
+func NewWebhook(secret string) (*InputWebhook, error) {
+	if secret == "" {
+		return nil, errors.New("secret cannot be empty")
+	}
+	return &InputWebhook{
+		secret: []byte(secret),
+		lock:   &sync.RWMutex{},
+		values: map[string]interface{}{},
+	}, nil
+}
+
+type InputWebhook struct {
+	secret []byte
+
+	lock      *sync.RWMutex
+	values    map[string]interface{}
+	listeners []func()
+}
+
+// OnChange registers a listener which is called after every applied update
+func (wh *InputWebhook) OnChange(fn func()) {
+	wh.lock.Lock()
+	defer wh.lock.Unlock()
+	wh.listeners = append(wh.listeners, fn)
+}
+
+func (wh *InputWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, WebhookMaxBodySize+1))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > WebhookMaxBodySize {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+	if !wh.verify(body, r.Header.Get(WebhookSignatureHeader)) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	update, err := decodeWebhookBody(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	wh.lock.Lock()
+	for k, v := range update {
+		if v == nil {
+			delete(wh.values, k)
+		} else {
+			wh.values[k] = v
+		}
+	}
+	var listeners = append([]func(){}, wh.listeners...)
+	wh.lock.Unlock()
+
+	for _, fn := range listeners {
+		fn()
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Sign returns the value of WebhookSignatureHeader for body,
+// for the senders written in Go and for tests
+func (wh *InputWebhook) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, wh.secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (wh *InputWebhook) verify(body []byte, signature string) bool {
+	return signature != "" && hmac.Equal([]byte(wh.Sign(body)), []byte(signature))
+}
+
+// decodeWebhookBody turns both supported body formats into key/values
+func decodeWebhookBody(body []byte) (map[string]interface{}, error) {
+	var raw interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, errors.New("body is not valid JSON")
+	}
+	var fhFeatures []FHValue
+	switch v := raw.(type) {
+	case []interface{}:
+		var envs []FeatureHubEnvironment
+		if err := json.Unmarshal(body, &envs); err != nil {
+			return nil, errors.New("body is not a list of feature-hub environments")
+		}
+		for _, env := range envs {
+			fhFeatures = append(fhFeatures, env.Features...)
+		}
+	case map[string]interface{}:
+		if list, ok := v["features"].([]interface{}); ok && len(list) > 0 {
+			var env FeatureHubEnvironment
+			if err := json.Unmarshal(body, &env); err == nil && env.Features[0].Key != "" {
+				fhFeatures = env.Features
+				break
+			}
+		}
+		return v, nil
+	default:
+		return nil, errors.New("body must be a JSON object or a list of feature-hub environments")
+	}
+
+	var update = make(map[string]interface{}, len(fhFeatures))
+	for _, f := range fhFeatures {
+		update[f.Key] = f.Value
+	}
+	return update, nil
+}
+
+func (wh *InputWebhook) value(key string) (interface{}, bool) {
+	wh.lock.RLock()
+	defer wh.lock.RUnlock()
+	v, ok := wh.values[key]
+	return v, ok
+}
+
+func (wh *InputWebhook) GetBoolean(key string) (bool, error) {
+	v, ok := wh.value(key)
+	if !ok {
+		return false, errors.New("key is not found")
+	}
+	switch vv := v.(type) {
+	case bool:
+		return vv, nil
+	case string:
+		return strconv.ParseBool(vv)
+	}
+	return false, errors.New("incompatible type for key=" + key)
+}
+
+func (wh *InputWebhook) GetNumber(key string) (float64, error) {
+	v, ok := wh.value(key)
+	if !ok {
+		return 0, errors.New("key is not found")
+	}
+	switch vv := v.(type) {
+	case float64:
+		return vv, nil
+	case string:
+		return strconv.ParseFloat(vv, 64)
+	}
+	return 0, errors.New("incompatible type for key=" + key)
+}
+
+// GetString returns strings as they are and objects as JSON text
+func (wh *InputWebhook) GetString(key string) (string, error) {
+	v, ok := wh.value(key)
+	if !ok {
+		return "", errors.New("key is not found")
+	}
+	switch vv := v.(type) {
+	case string:
+		return vv, nil
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(vv)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	return "", errors.New("incompatible type for key=" + key)
+}
+
+func (wh *InputWebhook) Has(key string) bool {
+	_, ok := wh.value(key)
+	return ok
+}
+
+// CanRefresh is false, the values are pushed to the webhook
+func (wh *InputWebhook) CanRefresh() bool {
+	return false
+}
+
+func (wh *InputWebhook) Reload() error {
+	return errors.New("is not implemented")
+}
+
+func (wh *InputWebhook) GetInputName() string {
+	return InputWebhookName
+}
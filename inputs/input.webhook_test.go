@@ -0,0 +1,68 @@
+package inputs
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pushToWebhook(wh *InputWebhook, body string, signature string) int {
+	req := httptest.NewRequest(http.MethodPost, "/config", bytes.NewBufferString(body))
+	req.Header.Set(WebhookSignatureHeader, signature)
+	rec := httptest.NewRecorder()
+	wh.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestWebhook_GenericBody(t *testing.T) {
+	wh, err := NewWebhook("secret")
+	assert.NoError(t, err)
+	var changes int
+	wh.OnChange(func() {
+		changes++
+	})
+
+	var body = `{"APP_HOST": "example.com", "APP_PORT": 8080, "APP_DEBUG": true, "LIMITS": {"rps": 10}}`
+	assert.Equal(t, http.StatusUnauthorized, pushToWebhook(wh, body, ""))
+	assert.Equal(t, http.StatusUnauthorized, pushToWebhook(wh, body, "sha256=00"))
+	assert.Equal(t, 0, changes)
+	assert.False(t, wh.Has("APP_HOST"))
+
+	assert.Equal(t, http.StatusNoContent, pushToWebhook(wh, body, wh.Sign([]byte(body))))
+	assert.Equal(t, 1, changes)
+	s, _ := wh.GetString("APP_HOST")
+	assert.Equal(t, "example.com", s)
+	n, _ := wh.GetNumber("APP_PORT")
+	assert.Equal(t, float64(8080), n)
+	b, _ := wh.GetBoolean("APP_DEBUG")
+	assert.True(t, b)
+	s, _ = wh.GetString("LIMITS")
+	assert.JSONEq(t, `{"rps": 10}`, s)
+
+	body = `{"APP_HOST": null}`
+	assert.Equal(t, http.StatusNoContent, pushToWebhook(wh, body, wh.Sign([]byte(body))))
+	assert.False(t, wh.Has("APP_HOST"))
+	assert.True(t, wh.Has("APP_PORT"))
+
+	body = `not json`
+	assert.Equal(t, http.StatusBadRequest, pushToWebhook(wh, body, wh.Sign([]byte(body))))
+}
+
+func TestWebhook_FeatureHubBody(t *testing.T) {
+	wh, _ := NewWebhook("secret")
+	var body = `{"id": "env-1", "features": [
+		{"id": "1", "key": "APP_HOST", "type": "STRING", "value": "example.com", "version": 2},
+		{"id": "2", "key": "APP_DEBUG", "type": "BOOLEAN", "value": false, "version": 2}]}`
+	assert.Equal(t, http.StatusNoContent, pushToWebhook(wh, body, wh.Sign([]byte(body))))
+	s, _ := wh.GetString("APP_HOST")
+	assert.Equal(t, "example.com", s)
+	assert.False(t, wh.Has("features"))
+
+	body = `[{"id": "env-1", "features": [{"id": "3", "key": "APP_PORT", "type": "NUMBER", "value": 9000}]}]`
+	assert.Equal(t, http.StatusNoContent, pushToWebhook(wh, body, wh.Sign([]byte(body))))
+	n, _ := wh.GetNumber("APP_PORT")
+	assert.Equal(t, float64(9000), n)
+}
@@ -0,0 +1,316 @@
+package ofprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	configmapper "mosix-go-configmapper"
+	"mosix-go-configmapper/flags"
+	"mosix-go-configmapper/inputs"
+
+	of "github.com/open-feature/go-sdk/openfeature"
+)
+
+const ProviderName = "mosix-go-configmapper"
+
+// MetadataSource is the flag metadata key which holds the name
+// of the input a flag is resolved from
+const MetadataSource = "source"
+
+// StaleReason is the reason of the flags resolved from an input which serves
+// stale values, e.g. FHInput started from a snapshot
+const StaleReason of.Reason = "STALE"
+
+// staleReporter is implemented by inputs which can serve stale
+// values, e.g. FHInput started from a snapshot
+type staleReporter interface {
+	IsStale() bool
+}
+
+// NewProvider
+// creates an OpenFeature provider which resolves flags through the inputs of the given
+// controller, in their priority order. The first input which has the flag with the
+// requested type wins, the same way FetchKeysAndMapThem resolves struct fields.
+func NewProvider(controller *configmapper.InputController) *Provider {
+	if controller == nil {
+		panic("controller cannot be nil")
+	}
+	return &Provider{
+		controller: controller,
+		skips:      map[string]reflect.StructTag{},
+		lock:       &sync.RWMutex{},
+	}
+}
+
+type Provider struct {
+	controller *configmapper.InputController
+
+	// in format of: map[flag]`skips:"inputName,..."`
+	skips map[string]reflect.StructTag
+	lock  *sync.RWMutex
+
+	hook flags.ExposureHook
+}
+
+// WithExposureHook makes every successful resolution be reported to hook,
+// the resolved value is used as the variant and targetingKey as the user key
+func (p *Provider) WithExposureHook(hook flags.ExposureHook) *Provider {
+	p.hook = hook
+	return p
+}
+
+// Skips makes the given inputs never be checked for the flag,
+// the same way 'skips' tag does for a struct field
+func (p *Provider) Skips(flag string, inputNames ...string) *Provider {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.skips[flag] = reflect.StructTag(fmt.Sprintf(`skips:"%s"`, strings.Join(inputNames, ",")))
+	return p
+}
+
+func (p *Provider) Metadata() of.Metadata {
+	return of.Metadata{Name: ProviderName}
+}
+
+func (p *Provider) Hooks() []of.Hook {
+	return nil
+}
+
+func (p *Provider) Init(evaluationContext of.EvaluationContext) error {
+	return nil
+}
+
+func (p *Provider) Shutdown() {
+}
+
+// Status is NOT_READY while any input is still loading its values,
+// STALE if any input serves stale values, and READY otherwise
+func (p *Provider) Status() of.State {
+	var state = of.ReadyState
+	for _, v := range p.controller.Inputs() {
+		if rn, ok := v.(inputs.ReadyNotifier); ok && !rn.IsReady() {
+			return of.NotReadyState
+		}
+		if sr, ok := v.(staleReporter); ok && sr.IsStale() {
+			state = of.StaleState
+		}
+	}
+	return state
+}
+
+func (p *Provider) BooleanEvaluation(ctx context.Context, flag string, defaultValue bool,
+	evalCtx of.FlattenedContext) of.BoolResolutionDetail {
+	v, detail := resolve(p, flag, evalCtx, func(in inputs.ValueInputInterface) (bool, error) {
+		return in.GetBoolean(flag)
+	})
+	if detail.ResolutionError != (of.ResolutionError{}) {
+		v = defaultValue
+	}
+	return of.BoolResolutionDetail{Value: v, ProviderResolutionDetail: detail}
+}
+
+func (p *Provider) StringEvaluation(ctx context.Context, flag string, defaultValue string,
+	evalCtx of.FlattenedContext) of.StringResolutionDetail {
+	v, detail := resolve(p, flag, evalCtx, func(in inputs.ValueInputInterface) (string, error) {
+		s, err := in.GetString(flag)
+		if err != nil {
+			return "", err
+		}
+		s, _, err = p.controller.CheckStringPreProcessors(s, nil)
+		return s, err
+	})
+	if detail.ResolutionError != (of.ResolutionError{}) {
+		v = defaultValue
+	}
+	return of.StringResolutionDetail{Value: v, ProviderResolutionDetail: detail}
+}
+
+func (p *Provider) FloatEvaluation(ctx context.Context, flag string, defaultValue float64,
+	evalCtx of.FlattenedContext) of.FloatResolutionDetail {
+	v, detail := resolve(p, flag, evalCtx, func(in inputs.ValueInputInterface) (float64, error) {
+		return in.GetNumber(flag)
+	})
+	if detail.ResolutionError != (of.ResolutionError{}) {
+		v = defaultValue
+	}
+	return of.FloatResolutionDetail{Value: v, ProviderResolutionDetail: detail}
+}
+
+func (p *Provider) IntEvaluation(ctx context.Context, flag string, defaultValue int64,
+	evalCtx of.FlattenedContext) of.IntResolutionDetail {
+	v, detail := resolve(p, flag, evalCtx, func(in inputs.ValueInputInterface) (float64, error) {
+		n, err := in.GetNumber(flag)
+		if err != nil {
+			return 0, err
+		}
+		if n != math.Trunc(n) {
+			return 0, fmt.Errorf("number %v is not an integer", n)
+		}
+		return n, nil
+	})
+	if detail.ResolutionError != (of.ResolutionError{}) {
+		return of.IntResolutionDetail{Value: defaultValue, ProviderResolutionDetail: detail}
+	}
+	return of.IntResolutionDetail{Value: int64(v), ProviderResolutionDetail: detail}
+}
+
+// ObjectEvaluation resolves the flag as a JSON text, with or
+// without json.object:: syntax, and decodes it
+func (p *Provider) ObjectEvaluation(ctx context.Context, flag string, defaultValue interface{},
+	evalCtx of.FlattenedContext) of.InterfaceResolutionDetail {
+	s, detail := resolve(p, flag, evalCtx, func(in inputs.ValueInputInterface) (string, error) {
+		return in.GetString(flag)
+	})
+	if detail.ResolutionError != (of.ResolutionError{}) {
+		return of.InterfaceResolutionDetail{Value: defaultValue, ProviderResolutionDetail: detail}
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(s, configmapper.SyntaxJsonObject)), &v); err != nil {
+		detail.ResolutionError = of.NewParseErrorResolutionError(fmt.Sprintf("flag %s is not a JSON object: %s", flag, err.Error()))
+		detail.Reason = of.ErrorReason
+		return of.InterfaceResolutionDetail{Value: defaultValue, ProviderResolutionDetail: detail}
+	}
+	return of.InterfaceResolutionDetail{Value: v, ProviderResolutionDetail: detail}
+}
+
+// resolve goes through the inputs which are not skipped for the flag and returns the first
+// value get returns without error. The inputs which evaluate targeting rules (flagd, Unleash
+// and GrowthBook) are bound to evalCtx first, see bindContext. If no input has the flag, the error is FLAG_NOT_FOUND
+// (or PROVIDER_NOT_READY if some inputs are still loading). If an input has the flag but
+// get fails, the error is TYPE_MISMATCH.
+func resolve[T any](p *Provider, flag string, evalCtx of.FlattenedContext, get func(in inputs.ValueInputInterface) (T, error)) (T, of.ProviderResolutionDetail) {
+	p.lock.RLock()
+	var tag = p.skips[flag]
+	p.lock.RUnlock()
+
+	var zero T
+	var mismatch error
+	var notReady bool
+	for _, in := range p.controller.Inputs() {
+		if p.controller.MustSkip(in.GetInputName(), &tag) {
+			continue
+		}
+		if rn, ok := in.(inputs.ReadyNotifier); ok && !rn.IsReady() {
+			notReady = true
+			continue
+		}
+		in = bindContext(in, evalCtx)
+		v, err := get(in)
+		if err == nil {
+			if p.hook != nil {
+				userKey, _ := evalCtx[of.TargetingKey].(string)
+				p.hook.OnExposure(flags.Exposure{
+					FlagKey:   flag,
+					Variant:   fmt.Sprint(v),
+					UserKey:   userKey,
+					Source:    in.GetInputName(),
+					Timestamp: time.Now(),
+				})
+			}
+			return v, of.ProviderResolutionDetail{
+				Reason:       reasonOf(in, flag),
+				FlagMetadata: of.FlagMetadata{MetadataSource: in.GetInputName()},
+			}
+		}
+		if mismatch == nil && in.Has(flag) {
+			mismatch = fmt.Errorf("flag %s in input %s has an incompatible type: %s", flag, in.GetInputName(), err.Error())
+		}
+	}
+
+	var detail = of.ProviderResolutionDetail{Reason: of.ErrorReason}
+	switch {
+	case mismatch != nil:
+		detail.ResolutionError = of.NewTypeMismatchResolutionError(mismatch.Error())
+	case notReady:
+		detail.ResolutionError = of.NewProviderNotReadyResolutionError(fmt.Sprintf("flag %s is not found and some inputs are not ready yet", flag))
+	default:
+		detail.ResolutionError = of.NewFlagNotFoundResolutionError(fmt.Sprintf("flag %s is not found in any of the inputs", flag))
+	}
+	return zero, detail
+}
+
+// bindContext returns the input bound to evalCtx if it evaluates targeting rules,
+// otherwise in itself. An empty evalCtx leaves the targeting out, as the inputs do.
+func bindContext(in inputs.ValueInputInterface, evalCtx of.FlattenedContext) inputs.ValueInputInterface {
+	if len(evalCtx) == 0 {
+		return in
+	}
+	switch v := in.(type) {
+	case *inputs.InputFlagd:
+		return v.WithEvaluationContext(evalCtx)
+	case *inputs.InputUnleash:
+		var ctx = inputs.UnleashContext{Properties: map[string]string{}}
+		for k, value := range evalCtx {
+			s, ok := value.(string)
+			if !ok {
+				continue
+			}
+			switch k {
+			case of.TargetingKey, "userId":
+				ctx.UserID = s
+			case "sessionId":
+				ctx.SessionID = s
+			case "remoteAddress":
+				ctx.RemoteAddress = s
+			default:
+				ctx.Properties[k] = s
+			}
+		}
+		return v.WithContext(ctx)
+	case *inputs.InputGrowthBook:
+		var attributes = make(map[string]interface{}, len(evalCtx)+1)
+		for k, value := range evalCtx {
+			attributes[k] = value
+		}
+		// targetingKey is GrowthBook's default hash attribute
+		if _, ok := attributes["id"]; !ok && evalCtx[of.TargetingKey] != nil {
+			attributes["id"] = evalCtx[of.TargetingKey]
+		}
+		return v.WithAttributes(attributes)
+	}
+	return in
+}
+
+// reasonOf returns the reason of the value of flag resolved from in: STALE if in serves
+// stale values, how the targeting rules resolved it for the inputs bound to a context,
+// and STATIC otherwise
+func reasonOf(in inputs.ValueInputInterface, flag string) of.Reason {
+	if sr, ok := in.(staleReporter); ok && sr.IsStale() {
+		return StaleReason
+	}
+	switch v := in.(type) {
+	case *inputs.InputFlagd:
+		_, _, reason, err := v.Evaluate(flag, v.EvaluationContext())
+		switch {
+		case err != nil:
+		case reason == inputs.FlagdReasonTargetingMatch:
+			return of.TargetingMatchReason
+		case reason == inputs.FlagdReasonDefault, reason == inputs.FlagdReasonError:
+			return of.DefaultReason
+		}
+	case *inputs.InputUnleash:
+		// the strategies of the toggles are evaluated against the bound context
+		var ctx = v.Context()
+		if ctx.UserID != "" || ctx.SessionID != "" || ctx.RemoteAddress != "" || len(ctx.Properties) > 0 {
+			return of.TargetingMatchReason
+		}
+	case *inputs.InputGrowthBook:
+		_, source, err := v.Evaluate(flag, v.Attributes())
+		switch {
+		case err != nil:
+		case source == inputs.GrowthBookSourceForce:
+			return of.TargetingMatchReason
+		case source == inputs.GrowthBookSourceExperiment:
+			return of.SplitReason
+		case v.Attributes() != nil:
+			return of.DefaultReason
+		}
+	}
+	return of.StaticReason
+}
@@ -0,0 +1,167 @@
+package ofprovider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	configmapper "mosix-go-configmapper"
+	"mosix-go-configmapper/flags"
+	"mosix-go-configmapper/inputs"
+
+	of "github.com/open-feature/go-sdk/openfeature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider_ResolvesThroughInputs(t *testing.T) {
+	input1Mock := inputs.NewInputMock()
+	input2Mock := inputs.NewInputMock()
+	input1Mock.KeysBool["NEW_CHECKOUT"] = true
+	input1Mock.KeysStr["THEME"] = "dark"
+	input1Mock.KeysStr["LIMITS"] = `json.object::{"rps": 10}`
+	input2Mock.KeysNumber["MAX_ITEMS"] = 25
+	input2Mock.KeysNumber["RATIO"] = 0.5
+
+	p := NewProvider(configmapper.NewInputController("name", "default", input1Mock, input2Mock))
+	var ctx = context.Background()
+
+	b := p.BooleanEvaluation(ctx, "NEW_CHECKOUT", false, nil)
+	assert.True(t, b.Value)
+	assert.Equal(t, of.StaticReason, b.Reason)
+	assert.Equal(t, inputs.InputMockName, b.FlagMetadata[MetadataSource])
+
+	assert.Equal(t, "dark", p.StringEvaluation(ctx, "THEME", "light", nil).Value)
+	assert.Equal(t, int64(25), p.IntEvaluation(ctx, "MAX_ITEMS", 0, nil).Value)
+	assert.Equal(t, 0.5, p.FloatEvaluation(ctx, "RATIO", 0, nil).Value)
+	assert.Equal(t, map[string]interface{}{"rps": float64(10)}, p.ObjectEvaluation(ctx, "LIMITS", nil, nil).Value)
+
+	notFound := p.StringEvaluation(ctx, "UNKNOWN", "fallback", nil)
+	assert.Equal(t, "fallback", notFound.Value)
+	assert.Equal(t, of.ErrorReason, notFound.Reason)
+	assert.Equal(t, of.FlagNotFoundCode, notFound.ResolutionDetail().ErrorCode)
+
+	mismatch := p.IntEvaluation(ctx, "RATIO", 7, nil)
+	assert.Equal(t, int64(7), mismatch.Value)
+	assert.Equal(t, of.TypeMismatchCode, mismatch.ResolutionDetail().ErrorCode)
+
+	input1Mock.KeysStr["NOT_JSON"] = "{"
+	parseErr := p.ObjectEvaluation(ctx, "NOT_JSON", "default", nil)
+	assert.Equal(t, "default", parseErr.Value)
+	assert.Equal(t, of.ParseErrorCode, parseErr.ResolutionDetail().ErrorCode)
+}
+
+func TestProvider_RespectsSkips(t *testing.T) {
+	input1Mock := inputs.NewInputMock()
+	input1Mock.KeysStr["THEME"] = "dark"
+
+	p := NewProvider(configmapper.NewInputController("name", "default", input1Mock)).
+		Skips("THEME", inputs.InputMockName)
+	res := p.StringEvaluation(context.Background(), "THEME", "light", nil)
+	assert.Equal(t, "light", res.Value)
+	assert.Equal(t, of.FlagNotFoundCode, res.ResolutionDetail().ErrorCode)
+}
+
+type exposureRecorder []flags.Exposure
+
+func (r *exposureRecorder) OnExposure(e flags.Exposure) {
+	*r = append(*r, e)
+}
+
+func TestProvider_ReportsExposures(t *testing.T) {
+	input1Mock := inputs.NewInputMock()
+	input1Mock.KeysStr["THEME"] = "dark"
+	var recorder exposureRecorder
+	p := NewProvider(configmapper.NewInputController("name", "default", input1Mock)).WithExposureHook(&recorder)
+
+	p.StringEvaluation(context.Background(), "THEME", "light", of.FlattenedContext{of.TargetingKey: "joe"})
+	p.StringEvaluation(context.Background(), "UNKNOWN", "light", of.FlattenedContext{of.TargetingKey: "joe"})
+	if assert.Len(t, recorder, 1) {
+		assert.Equal(t, "THEME", recorder[0].FlagKey)
+		assert.Equal(t, "dark", recorder[0].Variant)
+		assert.Equal(t, "joe", recorder[0].UserKey)
+		assert.Equal(t, inputs.InputMockName, recorder[0].Source)
+	}
+}
+
+func TestProvider_WithOpenFeatureClient(t *testing.T) {
+	input1Mock := inputs.NewInputMock()
+	input1Mock.KeysBool["NEW_CHECKOUT"] = true
+
+	assert.NoError(t, of.SetNamedProviderAndWait(t.Name(), NewProvider(configmapper.NewInputController("name", "default", input1Mock))))
+	client := of.NewClient(t.Name())
+	v, err := client.BooleanValue(context.Background(), "NEW_CHECKOUT", false, of.EvaluationContext{})
+	assert.NoError(t, err)
+	assert.True(t, v)
+
+	_, err = client.BooleanValue(context.Background(), "UNKNOWN", false, of.EvaluationContext{})
+	assert.Error(t, err)
+}
+
+// staleMock is an input which serves stale values
+type staleMock struct {
+	*inputs.InputMock
+}
+
+func (staleMock) IsStale() bool { return true }
+
+func TestProvider_EvaluatesTargetingPerContext(t *testing.T) {
+	var dir = t.TempDir()
+	var flagdPath = filepath.Join(dir, "flags.json")
+	assert.NoError(t, os.WriteFile(flagdPath, []byte(`{"flags": {"new-checkout": {"state": "ENABLED",
+		"variants": {"on": true, "off": false}, "defaultVariant": "off",
+		"targeting": {"if": [{"ends_with": [{"var": "email"}, "@example.com"]}, "on", null]}}}}`), 0o600))
+	fd, err := inputs.NewFlagdFile(flagdPath)
+	assert.NoError(t, err)
+	var gbPath = filepath.Join(dir, "features.json")
+	assert.NoError(t, os.WriteFile(gbPath, []byte(`{"features": {"dark-mode": {"defaultValue": false,
+		"rules": [{"condition": {"country": "NL"}, "force": true}]}}}`), 0o600))
+	gb, err := inputs.NewGrowthBookFile(gbPath, "")
+	assert.NoError(t, err)
+	stale := staleMock{inputs.NewInputMock()}
+	stale.KeysStr["THEME"] = "dark"
+
+	p := NewProvider(configmapper.NewInputController("name", "default", fd, gb, stale))
+	var ctx = context.Background()
+
+	b := p.BooleanEvaluation(ctx, "new-checkout", false, of.FlattenedContext{of.TargetingKey: "joe", "email": "joe@example.com"})
+	assert.True(t, b.Value)
+	assert.Equal(t, of.TargetingMatchReason, b.Reason)
+	b = p.BooleanEvaluation(ctx, "new-checkout", true, of.FlattenedContext{of.TargetingKey: "jane", "email": "jane@other.com"})
+	assert.False(t, b.Value)
+	assert.Equal(t, of.DefaultReason, b.Reason)
+	b = p.BooleanEvaluation(ctx, "new-checkout", true, nil)
+	assert.False(t, b.Value)
+	assert.Equal(t, of.StaticReason, b.Reason)
+
+	b = p.BooleanEvaluation(ctx, "dark-mode", false, of.FlattenedContext{of.TargetingKey: "joe", "country": "NL"})
+	assert.True(t, b.Value)
+	assert.Equal(t, of.TargetingMatchReason, b.Reason)
+	b = p.BooleanEvaluation(ctx, "dark-mode", true, of.FlattenedContext{of.TargetingKey: "jane", "country": "US"})
+	assert.False(t, b.Value)
+	assert.Equal(t, of.DefaultReason, b.Reason)
+
+	s := p.StringEvaluation(ctx, "THEME", "light", nil)
+	assert.Equal(t, "dark", s.Value)
+	assert.Equal(t, StaleReason, s.Reason)
+}
+
+func TestProvider_BindsUnleashContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"version":2,"features":[{"name":"beta","enabled":true,
+			"strategies":[{"name":"userWithId","parameters":{"userIds":"joe"}}]}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	un, err := inputs.NewUnleash(srv.URL, "token", "test")
+	assert.NoError(t, err)
+
+	p := NewProvider(configmapper.NewInputController("name", "default", un))
+	b := p.BooleanEvaluation(context.Background(), "beta", false, of.FlattenedContext{of.TargetingKey: "joe"})
+	assert.True(t, b.Value)
+	assert.Equal(t, of.TargetingMatchReason, b.Reason)
+	b = p.BooleanEvaluation(context.Background(), "beta", true, nil)
+	assert.False(t, b.Value)
+	assert.Equal(t, of.StaticReason, b.Reason)
+}
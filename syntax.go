@@ -14,4 +14,5 @@ const (
 	SyntaxURLEncode      Syntax = "url.encode::"
 	SyntaxURLDecode      Syntax = "url.decode::"
 	SyntaxURLParse       Syntax = "url::"
+	SyntaxFile           Syntax = "file::"
 )
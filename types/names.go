@@ -8,4 +8,9 @@ const (
 	VdLt        = "lessThan"
 	VdRequired  = "required"
 	VdProtocols = "protocols"
+	VdIPv4      = "ipv4"
+	VdIPv6      = "ipv6"
+	VdPrivate   = "private"
+	VdPublic    = "public"
+	VdWithin    = "within"
 )
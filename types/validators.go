@@ -3,6 +3,7 @@ package types
 import (
 	"errors"
 	"fmt"
+	"net/netip"
 	"strconv"
 	"strings"
 	"time"
@@ -180,3 +181,138 @@ func ValidateTimeDurations(t time.Duration,
 	}
 	return nil
 }
+
+// ParseTime parses a time rule with layout, or with time.RFC3339 if layout fails
+func ParseTime(v, layout string) (time.Time, error) {
+	t, err := time.Parse(layout, v)
+	if err == nil {
+		return t, nil
+	}
+	if t, err2 := time.Parse(time.RFC3339, v); err2 == nil {
+		return t, nil
+	}
+	return t, err
+}
+
+func ValidateRangeTime(v time.Time, layout, rule string) error {
+	if rule == "" {
+		return nil
+	}
+
+	if !strings.Contains(rule, "..") {
+		return fmt.Errorf("incorrect range value %s", rule)
+	}
+
+	times := strings.Split(rule, "..")
+	if len(times) != 2 {
+		return fmt.Errorf("range value (%s) is incorrect, it should be separated by .. (two dots)", rule)
+	}
+
+	t1, err := ParseTime(times[0], layout)
+	if err != nil {
+		return fmt.Errorf("range start value (%s) is not a time in %s layout", times[0], layout)
+	}
+	t2, err := ParseTime(times[1], layout)
+	if err != nil {
+		return fmt.Errorf("range end value (%s) is not a time in %s layout", times[1], layout)
+	}
+
+	if v.Before(t1) || v.After(t2) {
+		return fmt.Errorf("time %s is outside of the range %s", v.Format(layout), rule)
+	}
+	return nil
+}
+
+func ValidateGreaterThanTime(val time.Time, layout, rule string) error {
+	ruleT, err := ParseTime(rule, layout)
+	if err != nil {
+		return fmt.Errorf("GreaterThan rule for time %s is incorrect", rule)
+	}
+	if val.After(ruleT) {
+		return nil
+	}
+	return fmt.Errorf("time %s must be after %s", val.Format(layout), rule)
+}
+
+func ValidateLessThanTime(val time.Time, layout, rule string) error {
+	ruleT, err := ParseTime(rule, layout)
+	if err != nil {
+		return fmt.Errorf("LessThan rule for time %s is incorrect", rule)
+	}
+	if val.Before(ruleT) {
+		return nil
+	}
+	return fmt.Errorf("time %s must be before %s", val.Format(layout), rule)
+}
+
+// ValidateTimes validates timestamps, the values of the rules are parsed
+// with the layout of the field, or as time.RFC3339
+func ValidateTimes(t time.Time, layout string,
+	valRules map[string]string) error {
+	if v, ok := valRules[VdRange]; ok {
+		return ValidateRangeTime(t, layout, v)
+	} else if v, ok := valRules[VdGt]; ok {
+		return ValidateGreaterThanTime(t, layout, v)
+	} else if v, ok := valRules[VdLt]; ok {
+		return ValidateLessThanTime(t, layout, v)
+	}
+	return nil
+}
+
+// ValidateAddr validates an IP address against the ipv4, ipv6, private, public
+// and within rules, all the given rules must pass
+func ValidateAddr(addr netip.Addr, valRules map[string]string) error {
+	addr = addr.Unmap()
+	if _, ok := valRules[VdIPv4]; ok && !addr.Is4() {
+		return fmt.Errorf("address %s is not an IPv4 address", addr)
+	}
+	if _, ok := valRules[VdIPv6]; ok && !addr.Is6() {
+		return fmt.Errorf("address %s is not an IPv6 address", addr)
+	}
+	if _, ok := valRules[VdPrivate]; ok && !addr.IsPrivate() {
+		return fmt.Errorf("address %s is not in a private range", addr)
+	}
+	if _, ok := valRules[VdPublic]; ok && (!addr.IsGlobalUnicast() || addr.IsPrivate()) {
+		return fmt.Errorf("address %s is not a public address", addr)
+	}
+	if v, ok := valRules[VdWithin]; ok {
+		return ValidateWithin(netip.PrefixFrom(addr, addr.BitLen()), v)
+	}
+	return nil
+}
+
+// ValidatePrefix validates a CIDR by its address, for the within rule the
+// whole prefix must be contained by one of the prefixes of the rule
+func ValidatePrefix(prefix netip.Prefix, valRules map[string]string) error {
+	var rules = make(map[string]string, len(valRules))
+	for k, v := range valRules {
+		if k != VdWithin {
+			rules[k] = v
+		}
+	}
+	if err := ValidateAddr(prefix.Addr(), rules); err != nil {
+		return err
+	}
+	if v, ok := valRules[VdWithin]; ok {
+		return ValidateWithin(prefix, v)
+	}
+	return nil
+}
+
+// ValidateWithin checks that prefix is contained by any of the
+// comma separated prefixes of the rule, e.g. 10.0.0.0/8,192.168.0.0/16
+func ValidateWithin(prefix netip.Prefix, rule string) error {
+	for _, s := range strings.Split(rule, ",") {
+		within, err := netip.ParsePrefix(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("within rule %s is incorrect: %s", rule, err.Error())
+		}
+		if prefix.Bits() >= within.Bits() && within.Contains(prefix.Addr().Unmap()) {
+			return nil
+		}
+	}
+	if prefix.IsSingleIP() {
+		return fmt.Errorf("address %s is not within %s", prefix.Addr(), rule)
+	}
+	return fmt.Errorf("prefix %s is not within %s", prefix, rule)
+}
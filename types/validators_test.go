@@ -2,7 +2,9 @@ package types
 
 import (
 	"github.com/stretchr/testify/assert"
+	"net/netip"
 	"testing"
+	"time"
 )
 
 func TestValidateRange(t *testing.T) {
@@ -24,3 +26,30 @@ func TestValidateStringSet(t *testing.T) {
 	assert.Error(t, ValidateStringSet("a", "john,bob, bryan ,stephan"))
 	assert.NoError(t, ValidateStringSet("a", "john,bob,a,stephan"))
 }
+
+func TestValidateTimes(t *testing.T) {
+	cutover := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, ValidateTimes(cutover, time.DateOnly, map[string]string{VdGt: "2024-01-01"}))
+	assert.NoError(t, ValidateTimes(cutover, time.DateOnly, map[string]string{VdGt: "2024-01-01T00:00:00Z"}))
+	assert.Error(t, ValidateTimes(cutover, time.RFC3339, map[string]string{VdLt: "2024-01-01T00:00:00Z"}))
+	assert.NoError(t, ValidateTimes(cutover, time.DateOnly, map[string]string{VdRange: "2024-01-01..2024-12-31"}))
+	assert.Error(t, ValidateTimes(cutover, time.DateOnly, map[string]string{VdRange: "2025-01-01..2025-12-31"}))
+	assert.Error(t, ValidateTimes(cutover, time.DateOnly, map[string]string{VdGt: "soon"}))
+}
+
+func TestValidateAddr(t *testing.T) {
+	assert.NoError(t, ValidateAddr(netip.MustParseAddr("10.1.2.3"), map[string]string{VdIPv4: "", VdPrivate: "", VdWithin: "10.0.0.0/8"}))
+	assert.NoError(t, ValidateAddr(netip.MustParseAddr("::ffff:10.1.2.3"), map[string]string{VdIPv4: ""}))
+	assert.Error(t, ValidateAddr(netip.MustParseAddr("10.1.2.3"), map[string]string{VdIPv6: ""}))
+	assert.Error(t, ValidateAddr(netip.MustParseAddr("10.1.2.3"), map[string]string{VdPublic: ""}))
+	assert.NoError(t, ValidateAddr(netip.MustParseAddr("8.8.8.8"), map[string]string{VdPublic: ""}))
+	assert.Error(t, ValidateAddr(netip.MustParseAddr("127.0.0.1"), map[string]string{VdPublic: ""}))
+	assert.Error(t, ValidateAddr(netip.MustParseAddr("192.168.1.1"), map[string]string{VdWithin: "10.0.0.0/8, 172.16.0.0/12"}))
+	assert.Error(t, ValidateAddr(netip.MustParseAddr("10.1.2.3"), map[string]string{VdWithin: "bogus"}))
+}
+
+func TestValidatePrefix(t *testing.T) {
+	assert.NoError(t, ValidatePrefix(netip.MustParsePrefix("10.1.0.0/16"), map[string]string{VdWithin: "10.0.0.0/8"}))
+	assert.Error(t, ValidatePrefix(netip.MustParsePrefix("10.0.0.0/7"), map[string]string{VdWithin: "10.0.0.0/8"}))
+	assert.Error(t, ValidatePrefix(netip.MustParsePrefix("2001:db8::/32"), map[string]string{VdIPv4: ""}))
+}